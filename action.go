@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -13,26 +14,31 @@ type action struct{}
 
 func newAction() *action {
 	wo.Tm.Attach(model.NewTaskListener(nil, func(t model.Task) {
-		if wo.IsShowTaskDetail() && len(wo.Tm.Tasks) == 0 {
-			wo.ShowTaskDetail(false)
-		}
-		if mode == ModeJump || mode == ModeDisabled {
-			quitJumpMode()
-		}
-
-		if len(wo.Tm.Tasks) == 0 && mode == ModeTask {
-			changeMode(ModeNormal)
-		}
-		ui.TaskChangedEvent.Send(wo.Tm)
+		post(taskFinished)
 	}, func(t model.Task) {
 		ui.TaskChangedEvent.Send(wo.Tm)
 	}))
 	return new(action)
 }
 
+func taskFinished() {
+	if wo.IsShowTaskDetail() && len(wo.Tm.Tasks()) == 0 {
+		wo.ShowTaskDetail(false)
+	}
+	if mode == ModeJump || mode == ModeDisabled {
+		quitJumpMode()
+	}
+
+	if len(wo.Tm.Tasks()) == 0 && mode == ModeTask {
+		changeMode(ModeNormal)
+	}
+	ui.TaskChangedEvent.Send(wo.Tm)
+}
+
 func (w *action) sort(order model.Order) {
 	co := wo.CurrentGroup().Current()
 	co.Sort(order)
+	wo.Views.Save(co)
 	ui.ColumnContentChangeEvent.Send(co)
 }
 
@@ -45,12 +51,14 @@ func (w *action) toggleHidden() {
 	co := wo.CurrentGroup().Current()
 	co.ToggleHidden()
 	co.Update()
+	wo.Views.Save(co)
 	ui.ColumnContentChangeEvent.Send(co)
 }
 
 func (w *action) toggleDetails() {
 	co := wo.CurrentGroup().Current()
 	co.ToggleDetail()
+	wo.Views.Save(co)
 	ui.ToggleDetailEvent.Send(co)
 }
 
@@ -80,18 +88,17 @@ func (w *action) moveToLast() {
 
 func (w *action) openRight() {
 	gu := wo.CurrentGroup()
-	gu.Record()
-
-	err := gu.OpenDir()
+	fi, err := gu.Current().CurrentFile()
 	if err != nil {
 		ui.MessageEvent.Send(err.Error())
 		return
 	}
 
-	if len(gu.Columns()) >= maxColumns {
-		gu.Shift()
+	if !fi.IsDir() {
+		openFile(gu, fi)
+		return
 	}
-	ui.OpenRightEvent.Send(gu)
+	openLoading(gu, fi)
 }
 
 func (w *action) closeRight() {
@@ -127,10 +134,16 @@ func (w *action) toggleBookmark() {
 	ui.ToggleBookmarkEvent.Send(wo.IsShowBookmark())
 }
 
+func (w *action) togglePreview() {
+	wo.TogglePreview()
+	ui.TogglePreviewEvent.Send(wo.IsShowPreview())
+	resetPreview()
+}
+
 func (w *action) changeGroup(idx int) {
 	wo.Current = idx
 	if wo.Groups[idx] == nil {
-		g, _ := model.NewLocalGroup(wd)
+		g, _ := model.NewLocalGroup(wd, wo.Views)
 		wo.Groups[idx] = g
 	}
 	ui.ChangeGroupEvent.Send(wo)
@@ -148,6 +161,58 @@ func (w *action) openRoot(path string) {
 	ui.ChangeRootEvent.Send(gu)
 }
 
+// goToPath open path with its parents in the columns before it, the opened path is added to the recent paths
+// the dirs are loaded and read in background like openFile, esc cancels it
+func (w *action) goToPath(path string) {
+	p, err := resolvePath(path)
+	if err != nil {
+		ui.MessageEvent.Send("Can not open " + path + ": " + err.Error())
+		return
+	}
+	path = p
+	depth := maxColumns - 1
+	if depth < 1 {
+		depth = 1
+	}
+
+	gu := wo.CurrentGroup()
+	cancelLoading()
+	ctx, cancel := context.WithCancel(context.Background())
+	loadCancel, loadGroup, loadColumn = cancel, gu, nil
+	ui.MessageEvent.Send("Opening " + path + ", press esc to cancel")
+
+	timeout := cfg.loadTimeout
+	startJob(func() {
+		dirs, err := model.PathDirs(ctx, path, depth)
+		items := make([][]model.FileItem, len(dirs))
+		for i := 0; err == nil && i < len(dirs); i++ {
+			items[i], err = readDir(ctx, dirs[i], timeout, nil)
+		}
+
+		post(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			cancel()
+			loadCancel, loadGroup = nil, nil
+
+			if err != nil {
+				ui.MessageEvent.Send("Can not open " + path + ": " + err.Error())
+				return
+			}
+			if wo.CurrentGroup() != gu || mode != ModeNormal {
+				return
+			}
+
+			gu.Record()
+			gu.OpenPath(dirs, items)
+			wo.Paths.Add(gu.Path())
+			ui.MessageEvent.Send("")
+			ui.ChangeRootEvent.Send(gu)
+		})
+	})
+}
+
 func (w *action) jumpTo(colIdx, fileIdx int, openIt bool) bool {
 	gu := wo.CurrentGroup()
 	suc := gu.JumpTo(colIdx, fileIdx)
@@ -202,6 +267,7 @@ func (w *action) clearFilter() {
 	co := wo.CurrentGroup().Current()
 	co.SetFilter("")
 	co.Update()
+	wo.Views.Save(co)
 	ui.ColumnContentChangeEvent.Send(co)
 }
 
@@ -364,10 +430,7 @@ func (w *action) openFile() {
 		return
 	}
 
-	err = file.(model.Op).Open()
-	if err != nil {
-		ui.MessageEvent.Send(err.Error())
-	}
+	openByRules(file)
 }
 
 func (w *action) clipFile() {
@@ -446,7 +509,7 @@ func (w *action) deleteClip(idx int) {
 }
 
 func (w *action) showTaskDetail() {
-	if len(wo.Tm.Tasks) == 0 || wo.IsShowTaskDetail() {
+	if len(wo.Tm.Tasks()) == 0 || wo.IsShowTaskDetail() {
 		return
 	}
 
@@ -466,13 +529,13 @@ func (w *action) closeTaskDetail() {
 }
 
 func (w *action) fakeTask() {
-	fn := func(progress chan<- int, quit <-chan bool, err chan<- error) {
+	fn := func(ctx context.Context, progress chan<- int, err chan<- error) {
 		defer close(err)
 		defer close(progress)
 		i := 0
 		for i <= 100 {
 			select {
-			case <-quit:
+			case <-ctx.Done():
 				return
 			case <-time.After(50 * time.Millisecond):
 				progress <- i
@@ -487,6 +550,27 @@ func (w *action) fakeTask() {
 	wo.Tm.Submit(t)
 }
 
+// throttleInputer ask the rate limit of t
+func throttleInputer(t model.Task) Inputer {
+	return newRecordedInput("RATE LIMIT", "rate", func(str string) { ac.throttle(t, str) })
+}
+
+func (w *action) throttle(t model.Task, str string) {
+	rate, err := model.ParseRate(str)
+	if err != nil {
+		ui.MessageEvent.Send(err.Error())
+		return
+	}
+
+	t.Throttle(rate)
+	ui.TaskChangedEvent.Send(wo.Tm)
+	if rate == 0 {
+		ui.MessageEvent.Send(fmt.Sprintf("Rate limit of %s removed", t.Name()))
+		return
+	}
+	ui.MessageEvent.Send(fmt.Sprintf("Rate of %s limited to %s/s", t.Name(), str))
+}
+
 func (w *action) copyFile() {
 	if wo.Clip == nil {
 		ui.MessageEvent.Send("No clipped files")
@@ -502,16 +586,41 @@ func (w *action) copyFile() {
 		return
 	}
 
+	ui.ClipChangedEvent.Send(nil)
+	submitTask(task)
+}
+
+// submitTask run the task, its errors are shown as messages
+func submitTask(task model.Task) {
 	msg := wo.Tm.Submit(task)
 	go func() {
 		for v := range msg {
 			ui.MessageEvent.Send(v)
 		}
 	}()
-	ui.Batch(
-		ui.ClipChangedEvent.With(nil),
-		ui.TaskChangedEvent.With(wo.Tm),
-	)
+	ui.TaskChangedEvent.Send(wo.Tm)
+}
+
+// offerResume ask to resume the copies not finished when fff quit last time, they are discarded if declined
+func offerResume() {
+	js := model.PendingJournals()
+	if len(js) == 0 {
+		return
+	}
+
+	title := fmt.Sprintf("%d unfinished copies found, resume them? (y/n)", len(js))
+	if len(js) == 1 {
+		title = fmt.Sprintf("Unfinished copy found (%s), resume it? (y/n)", js[0])
+	}
+	askUser(title, false, func(answer string) {
+		for _, v := range js {
+			if answer == "y" {
+				submitTask(v.Resume())
+			} else {
+				v.Discard()
+			}
+		}
+	})
 }
 
 func (w *action) moveFile() {
@@ -553,33 +662,38 @@ func (w *action) closeHelp() {
 	ui.ShowHelpEvent.Send(false)
 }
 
-func (w *action) shell() error {
+// shell, edit and view return the command to run after the ui is closed
+// it runs out of the state loop, so the workspace is not touched by it
+func (w *action) shell() func() error {
 	dir := wo.CurrentGroup().Current().File()
-	return dir.(model.DirOp).Shell()
+	return dir.(model.DirOp).Shell
 }
 
-func (w *action) edit() error {
+// selectedFileOp the op of the selected file, a link to a dir is a dir though it has the op
+func selectedFileOp() (model.FileOp, error) {
 	file, err := wo.CurrentGroup().Current().CurrentFile()
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	err = file.(model.FileOp).Edit()
-	if err != nil {
-		return err
+	op, ok := file.(model.FileOp)
+	if !ok || file.IsDir() {
+		return nil, fmt.Errorf("%s is a dir", file.Name())
 	}
-	return nil
+	return op, nil
 }
 
-func (w *action) view() error {
-	file, err := wo.CurrentGroup().Current().CurrentFile()
+func (w *action) edit() func() error {
+	op, err := selectedFileOp()
 	if err != nil {
-		return err
+		return func() error { return err }
 	}
+	return op.Edit
+}
 
-	err = file.(model.FileOp).View()
+func (w *action) view() func() error {
+	op, err := selectedFileOp()
 	if err != nil {
-		return err
+		return func() error { return err }
 	}
-	return nil
+	return op.View
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+const sumsHelp = "[j/k]select  [w]write " + model.SumsFile + "  [q]close"
+
+// sumsViewer state of the checksum view
+// the sums finished in other modes are pending until the checksum action is taken again
+type sumsViewer struct {
+	dir      model.FileItem
+	sums     []model.Sum
+	selected int
+	pending  bool
+}
+
+var sumsView = new(sumsViewer)
+
+// start compute the checksums of the marked or selected files in a task, they are shown when it finished
+// the sums of a canceled or failed walk are dropped
+func (s *sumsViewer) start() {
+	if s.pending {
+		s.open()
+		return
+	}
+
+	co := wo.CurrentGroup().Current()
+	dir := co.File()
+	task, sums := model.NewChecksumTask(dir.Path(), co.MarkedOrSelected())
+
+	task.Attach(model.NewListener(nil, func() {
+		post(func() {
+			if !sums.IsFinished() {
+				return
+			}
+			s.dir, s.sums, s.selected, s.pending = dir, sums.List(), 0, true
+			if mode != ModeNormal {
+				ui.MessageEvent.Send("Checksums are ready, checksum again to show them")
+				return
+			}
+			s.open()
+		})
+	}))
+	submitTask(task)
+}
+
+func (s *sumsViewer) open() {
+	s.pending = false
+	changeMode(ModeSums)
+	s.render(sumsHelp)
+}
+
+func (s *sumsViewer) close() {
+	s.dir, s.sums = nil, nil
+	changeMode(ModeNormal)
+	ui.SumsEvent.Send(nil)
+}
+
+func (s *sumsViewer) render(msg string) {
+	ui.SumsEvent.Send(&ui.SumsData{Dir: s.dir.Path(), Sums: s.sums, Selected: s.selected})
+	if msg != "" {
+		ui.MessageEvent.Send(msg)
+	}
+}
+
+func (s *sumsViewer) move(n int) {
+	s.selected += n
+	if s.selected >= len(s.sums) {
+		s.selected = len(s.sums) - 1
+	}
+	if s.selected < 0 {
+		s.selected = 0
+	}
+	s.render("")
+}
+
+// write the sums into the dir the files are selected in
+func (s *sumsViewer) write() {
+	if err := model.WriteSums(s.dir, s.sums); err != nil {
+		s.render(err.Error())
+		return
+	}
+
+	path := s.dir.Path()
+	s.close()
+	co := wo.CurrentGroup().Current()
+	if co.Path() == path {
+		co.Refresh(nil)
+		ui.ColumnContentChangeEvent.Send(co)
+	}
+	ui.MessageEvent.Send(fmt.Sprintf("%s is written to %s", model.SumsFile, path))
+}
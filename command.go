@@ -0,0 +1,457 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+// command of the command line, such as :sort mtime desc
+type command struct {
+	usage string
+	run   func(args []string) error
+
+	// paths if the args are paths, they are completed from the current dir
+	paths bool
+
+	// words the candidates of the ith arg, nil if the arg can not be completed
+	words func(i int) []string
+}
+
+var (
+	commands map[string]*command
+
+	// actionCommands the commands run an action
+	actionCommands = map[string]string{
+		"refresh":  "ActionRefresh",
+		"back":     "ActionGoBack",
+		"help":     "ActionShowHelp",
+		"paste":    "ActionPaste",
+		"move":     "ActionMoveFile",
+		"delete":   "ActionDeleteFile",
+		"hex":      "ActionHexView",
+		"history":  "ActionShowTaskHistory",
+		"checksum": "ActionChecksum",
+		"reload":   "ActionReloadConfig",
+	}
+
+	// setOptions the options of :set, :set hidden shows the hidden files, :set nohidden hides them
+	// and :set hidden! toggles them
+	setOptions = map[string]struct {
+		get    func() bool
+		toggle func()
+	}{
+		"hidden":   {func() bool { return wo.CurrentGroup().Current().IsShowHidden() }, func() { ac.toggleHidden() }},
+		"detail":   {func() bool { return wo.CurrentGroup().Current().IsShowDetail() }, func() { ac.toggleDetails() }},
+		"preview":  {func() bool { return wo.IsShowPreview() }, func() { ac.togglePreview() }},
+		"bookmark": {func() bool { return wo.IsShowBookmark() }, func() { ac.toggleBookmark() }},
+	}
+)
+
+func init() {
+	commands = map[string]*command{
+		"cd":       {"cd PATH", cmdCd, true, nil},
+		"mkdir":    {"mkdir [-p] DIR...", cmdMkdir, true, nil},
+		"rename":   {"rename [NAME]", cmdRename, true, nil},
+		"sort":     {"sort name|mtime|size [asc|desc]", cmdSort, false, sortWords},
+		"filter":   {"filter [TEXT]", cmdFilter, false, nil},
+		"bookmark": {"bookmark add NAME [PATH] | bookmark del NAME", cmdBookmark, false, bookmarkWords},
+		"tab":      {"tab N", cmdTab, false, nil},
+		"set":      {"set [no]OPTION | set OPTION!", cmdSet, false, setWords},
+		"action":   {"action NAME", cmdAction, false, actionWords},
+		"run":      {"run NAME", cmdRun, false, runWords},
+		"quit":     {"quit", cmdQuit, false, nil},
+	}
+	for k, v := range actionCommands {
+		action := v
+		commands[k] = &command{k, func(args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+			actions[action]()
+			return nil
+		}, false, nil}
+	}
+}
+
+var errUsage = errors.New("usage")
+
+// word a word of command line, start is where it starts in the line
+type word struct {
+	value string
+	start int
+}
+
+// splitWords split a command line into words by spaces, a space can be in single or double quotes, or escaped by a backslash
+// the last word is empty if line ends with a space
+func splitWords(line string) ([]word, error) {
+	re := make([]word, 0)
+	var cur []rune
+	start, in, quote, escaped := 0, false, rune(0), false
+	for i, ch := range line {
+		switch {
+		case escaped:
+			cur, escaped = append(cur, ch), false
+		case ch == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+			cur = append(cur, ch)
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == ' ' || ch == '\t':
+			if in {
+				re = append(re, word{string(cur), start})
+				cur, in = nil, false
+			}
+			continue
+		default:
+			cur = append(cur, ch)
+		}
+		if !in {
+			start, in = i, true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote %c", quote)
+	}
+	if in {
+		re = append(re, word{string(cur), start})
+	} else {
+		re = append(re, word{"", len(line)})
+	}
+	return re, nil
+}
+
+// escapeWord escape the spaces, quotes and backslashes in str
+func escapeWord(str string) string {
+	return wordEscaper.Replace(str)
+}
+
+var wordEscaper = strings.NewReplacer(`\`, `\\`, " ", `\ `, `'`, `\'`, `"`, `\"`, "\t", "\\\t")
+
+// runCommand parse and run line, the errors are shown in the status bar
+func runCommand(line string) {
+	ws, err := splitWords(line)
+	if err != nil {
+		ui.MessageEvent.Send(err.Error())
+		return
+	}
+	if ws[len(ws)-1].value == "" {
+		ws = ws[:len(ws)-1]
+	}
+	if len(ws) == 0 {
+		return
+	}
+
+	name := ws[0].value
+	c, ok := commands[name]
+	if !ok {
+		ui.MessageEvent.Send("Unknown command: " + name)
+		return
+	}
+	if mode != ModeNormal {
+		ui.MessageEvent.Send(name + ": commands can only be run in normal mode")
+		return
+	}
+
+	args := make([]string, len(ws)-1)
+	for i, v := range ws[1:] {
+		args[i] = v.value
+	}
+	err = c.run(args)
+	if err == errUsage {
+		err = errors.New("usage: " + c.usage)
+	}
+	if err != nil {
+		ui.MessageEvent.Send(fmt.Sprintf("%s: %s", name, err.Error()))
+	}
+}
+
+// commandInputer the input of the command line
+type commandInputer struct {
+	*nameInputer
+}
+
+var commandLineInputer = &commandInputer{newRecordedInput(":", "command", runCommand)}
+
+// Complete the command name, or the last arg of the command
+func (ci *commandInputer) Complete(ctx context.Context, dir model.FileItem, before string) ([]string, error) {
+	ws, err := splitWords(before)
+	if err != nil {
+		return nil, nil
+	}
+	last := ws[len(ws)-1]
+	prefix := before[:last.start]
+
+	var cs []string
+	if len(ws) == 1 {
+		for k := range commands {
+			cs = append(cs, k)
+		}
+		return completeWords(prefix, last.value, cs), nil
+	}
+
+	c, ok := commands[ws[0].value]
+	switch {
+	case !ok:
+		return nil, nil
+	case c.paths:
+		cs, err = model.Complete(ctx, dir, expandHome(last.value))
+		re := make([]string, len(cs))
+		for i, v := range cs {
+			re[i] = prefix + escapeWord(v)
+		}
+		return re, err
+	case c.words != nil:
+		return completeWords(prefix, last.value, c.words(len(ws)-2)), nil
+	}
+	return nil, nil
+}
+
+// completeWords the words started with w, a space is appended for the next arg
+func completeWords(prefix, w string, words []string) []string {
+	re := make([]string, 0)
+	for _, v := range words {
+		if strings.HasPrefix(v, w) {
+			re = append(re, prefix+escapeWord(v)+" ")
+		}
+	}
+	sort.Strings(re)
+	return re
+}
+
+func sortWords(i int) []string {
+	switch i {
+	case 0:
+		return []string{"name", "mtime", "size"}
+	case 1:
+		return []string{"asc", "desc"}
+	}
+	return nil
+}
+
+func bookmarkWords(i int) []string {
+	if i == 0 {
+		return []string{"add", "del"}
+	}
+	return nil
+}
+
+func setWords(i int) []string {
+	if i != 0 {
+		return nil
+	}
+	re := make([]string, 0, len(setOptions)*3)
+	for k := range setOptions {
+		re = append(re, k, "no"+k, k+"!")
+	}
+	return re
+}
+
+func actionWords(i int) []string {
+	if i != 0 {
+		return nil
+	}
+	re := make([]string, 0, len(actions))
+	for k := range actions {
+		re = append(re, k)
+	}
+	return re
+}
+
+// expandHome replace the leading ~ with the home dir
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		return home + p[1:]
+	}
+	return p
+}
+
+// resolvePath the path of p from the current dir, it fails if a loader of the path is unknown
+func resolvePath(p string) (string, error) {
+	p = expandHome(p)
+	if !filepath.IsAbs(p) {
+		cur := wo.CurrentGroup().Current().Path()
+		pis, err := model.ParsePath(cur)
+		if err != nil {
+			return "", err
+		}
+		if len(pis) == 1 {
+			p = filepath.Join(cur, p)
+		} else {
+			last := pis[len(pis)-1]
+			p = cur[:len(cur)-len(last.Path)] + path.Join(last.Path, p)
+		}
+	}
+
+	if _, err := model.ParsePath(p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+func cmdCd(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	ac.goToPath(args[0])
+	return nil
+}
+
+func cmdMkdir(args []string) error {
+	parents := len(args) > 0 && args[0] == "-p"
+	if parents {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	g := wo.CurrentGroup()
+	op := g.Current().File().(model.DirOp)
+	for _, v := range args {
+		if parent := path.Dir(strings.TrimRight(v, "/")); !parents && parent != "." {
+			if _, err := op.To(parent); err != nil {
+				return fmt.Errorf("%s does not exist, use -p to create it", parent)
+			}
+		}
+		if err := op.NewDir(v); err != nil {
+			return err
+		}
+	}
+
+	g.Refresh()
+	g.Current().SelectByName(strings.SplitN(args[0], "/", 2)[0])
+	ui.ColumnContentChangeEvent.Send(g.Current())
+	return nil
+}
+
+func cmdRename(args []string) error {
+	switch len(args) {
+	case 0:
+		startRename()
+	case 1:
+		ac.rename(args[0])
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+func cmdSort(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errUsage
+	}
+	order, err := model.ParseOrder(args[0])
+	if err != nil {
+		return err
+	}
+
+	// name is ascending by default, mtime and size are descending
+	if len(args) == 2 {
+		switch args[1] {
+		case "asc":
+			if order != model.OrderByName {
+				order |= model.OrderReverse
+			}
+		case "desc":
+			if order == model.OrderByName {
+				order |= model.OrderReverse
+			}
+		default:
+			return errUsage
+		}
+	}
+	ac.sort(order)
+	return nil
+}
+
+func cmdFilter(args []string) error {
+	co := wo.CurrentGroup().Current()
+	co.SetFilter(strings.Join(args, " "))
+	co.Update()
+	wo.Views.Save(co)
+	ui.ColumnContentChangeEvent.Send(co)
+	return nil
+}
+
+func cmdBookmark(args []string) error {
+	switch {
+	case len(args) == 2 && args[0] == "add":
+		ac.addBookmark(args[1], wo.CurrentGroup().Path())
+	case len(args) == 3 && args[0] == "add":
+		p, err := resolvePath(args[2])
+		if err != nil {
+			return err
+		}
+		ac.addBookmark(args[1], p)
+	case len(args) == 2 && args[0] == "del":
+		ac.deleteBookmark(args[1])
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+func cmdTab(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(wo.Groups) {
+		return fmt.Errorf("tab must be 1 to %d", len(wo.Groups))
+	}
+	ac.changeGroup(n - 1)
+	return nil
+}
+
+func cmdSet(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	name, want := args[0], true
+	toggle := strings.HasSuffix(name, "!")
+	name = strings.TrimSuffix(name, "!")
+	opt, ok := setOptions[name]
+	if !ok && strings.HasPrefix(name, "no") && !toggle {
+		name, want = name[2:], false
+		opt, ok = setOptions[name]
+	}
+	if !ok {
+		return fmt.Errorf("unknown option: %s", args[0])
+	}
+
+	if toggle || opt.get() != want {
+		opt.toggle()
+	}
+	return nil
+}
+
+func cmdAction(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	fn, ok := actions[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown action: %s", args[0])
+	}
+	fn()
+	return nil
+}
+
+func cmdQuit(args []string) error {
+	stopUI(1)
+	return nil
+}
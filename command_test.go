@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+func TestLoopCommand(t *testing.T) {
+	tmp := tempDir(t, ".hidden", "sbig", "small")
+	defer os.RemoveAll(tmp)
+	ioutil.WriteFile(filepath.Join(tmp, "sbig"), make([]byte, 100), 0644)
+	startLoop(tmp)
+
+	for _, v := range []string{"mkdir -p a/b\\ c/d", "sort size asc", "set hidden", "filter s"} {
+		sendKeys(":" + v)
+		sendKey(termbox.KeyEnter)
+	}
+	waitLoop()
+
+	if _, err := os.Stat(filepath.Join(tmp, "a", "b c", "d")); err != nil {
+		t.Error(err)
+	}
+	post(func() {
+		co := wo.CurrentGroup().Current()
+		if co.Order() != model.OrderBySize|model.OrderReverse || !co.IsShowHidden() || co.Filter() != "s" {
+			t.Errorf("commands are not applied: %s %t %s", co.Order(), co.IsShowHidden(), co.Filter())
+		}
+		names := ""
+		for _, v := range co.Files() {
+			names += v.Name() + " "
+		}
+		if names != "small sbig " {
+			t.Errorf("expect small sbig, got %s", names)
+		}
+	})
+	waitLoop()
+}
+
+func TestSplitWords(t *testing.T) {
+	ws, err := splitWords(`cd "a b"  c\ d 'e\f' `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(ws))
+	for i, v := range ws {
+		got[i] = fmt.Sprintf("%s@%d", v.value, v.start)
+	}
+	if s := fmt.Sprint(got); s != `[cd@0 a b@3 c d@10 e\f@15 @21]` {
+		t.Errorf("unexpected words %s", s)
+	}
+	if _, err := splitWords(`cd "a`); err == nil {
+		t.Error("unterminated quote is accepted")
+	}
+}
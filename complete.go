@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+// completer an inputer whose text can be completed by tab
+type completer interface {
+	// Complete the candidates replacing before, the text before cursor. it runs out of the state loop,
+	// dir is the current dir when tab is pressed
+	Complete(ctx context.Context, dir model.FileItem, before string) ([]string, error)
+}
+
+// pathInputer a name input completing the paths from the current dir
+type pathInputer struct {
+	*nameInputer
+}
+
+func newPathInput(title, kind string, action func(string)) *pathInputer {
+	return &pathInputer{newRecordedInput(title, kind, action)}
+}
+
+func (pi *pathInputer) Complete(ctx context.Context, dir model.FileItem, before string) ([]string, error) {
+	return model.Complete(ctx, dir, before)
+}
+
+// gotoInputer the input of go to path, the recent paths started with the input are the first candidates
+type gotoInputer struct {
+	*nameInputer
+	recent *model.Recent
+}
+
+func newGotoInput(recent *model.Recent) *gotoInputer {
+	return &gotoInputer{newRecordedInput("GO TO", "goto", func(path string) { ac.goToPath(path) }), recent}
+}
+
+func (gi *gotoInputer) Complete(ctx context.Context, dir model.FileItem, before string) ([]string, error) {
+	re := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, v := range gi.recent.List() {
+		if strings.HasPrefix(v, before) {
+			re = append(re, v)
+			seen[v] = true
+		}
+	}
+
+	cs, err := model.Complete(ctx, dir, expandHome(before))
+	for _, v := range cs {
+		if !seen[v] {
+			re = append(re, v)
+		}
+	}
+	if len(re) != 0 {
+		return re, nil
+	}
+	return nil, err
+}
+
+// completion the candidates found by tab, the next tab selects the next one
+type completion struct {
+	candidates []string
+	selected   int
+	after      string
+}
+
+var (
+	completing     *completion
+	completeCancel context.CancelFunc
+)
+
+func (c *completion) apply() {
+	editor.reset(c.candidates[c.selected] + c.after)
+	editor.cursor = len(editor.line) - len([]rune(c.after))
+	if v := editor.String(); v != inputer.Get() {
+		inputer.Set(v)
+	}
+	sendInputChange()
+	if len(c.candidates) > 1 {
+		ui.CompletionEvent.Send(&ui.CompletionData{Candidates: c.candidates, Selected: c.selected})
+	}
+}
+
+// inputComplete complete the text before cursor, or select the next candidate if it is completed already
+// the candidates are read in background, they are dropped if the input is changed before
+func inputComplete() {
+	if completing != nil {
+		completing.selected = (completing.selected + 1) % len(completing.candidates)
+		completing.apply()
+		return
+	}
+
+	c, ok := inputer.(completer)
+	if !ok {
+		return
+	}
+	cancelCompletion()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	completeCancel = cancel
+	in, dir := inputer, wo.CurrentGroup().Current().File()
+	before, after := string(editor.line[:editor.cursor]), string(editor.line[editor.cursor:])
+	startJob(func() {
+		cs, err := c.Complete(ctx, dir, before)
+		post(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			cancel()
+			completeCancel = nil
+			// a message would cover the input, so nothing is shown if there is no candidate
+			if inputer != in || err != nil || len(cs) == 0 {
+				return
+			}
+
+			if len(cs) == 1 {
+				(&completion{cs, 0, after}).apply()
+				return
+			}
+			completing = &completion{cs, 0, after}
+			completing.apply()
+		})
+	})
+}
+
+// cancelCompletion stop the running completion and close the candidates
+func cancelCompletion() {
+	if completeCancel != nil {
+		completeCancel()
+		completeCancel = nil
+	}
+	if completing != nil {
+		completing = nil
+		ui.CompletionEvent.Send(nil)
+	}
+}
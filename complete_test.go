@@ -0,0 +1,99 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+func TestLoopGoToPath(t *testing.T) {
+	tmp := tempDir(t)
+	defer os.RemoveAll(tmp)
+	os.MkdirAll(filepath.Join(tmp, "a", "b", "c"), 0755)
+	zp := filepath.Join(tmp, "a", "x.zip")
+	f, _ := os.Create(zp)
+	zw := zip.NewWriter(f)
+	zw.Create("sub/one")
+	zw.Close()
+	f.Close()
+	startLoop(tmp)
+
+	paths := func() []string {
+		ch := make(chan []string)
+		post(func() {
+			re := make([]string, 0)
+			for _, v := range wo.CurrentGroup().Columns() {
+				re = append(re, v.Path())
+			}
+			ch <- re
+		})
+		return <-ch
+	}
+	// the dirs are opened in background
+	opened := func() []string {
+		waitJobs(0)
+		return paths()
+	}
+
+	// the parents are opened before the target, up to the columns can be shown
+	sendKeys("oa/b/c")
+	sendKey(termbox.KeyEnter)
+	if ps := opened(); fmt.Sprint(ps) != fmt.Sprint([]string{filepath.Join(tmp, "a", "b"), filepath.Join(tmp, "a", "b", "c")}) {
+		t.Errorf("unexpected columns %v", ps)
+	}
+
+	sendKeys(":cd " + zp + "@zip:///sub")
+	sendKey(termbox.KeyEnter)
+	zipped := fmt.Sprint([]string{zp + "@zip:///", zp + "@zip:///sub"})
+	if ps := opened(); fmt.Sprint(ps) != zipped {
+		t.Errorf("unexpected columns %v", ps)
+	}
+
+	// a path with an unknown loader is not opened
+	sendKeys("o" + tmp + "@bogus://x")
+	sendKey(termbox.KeyEnter)
+	sendKeys(":cd x@bogus://y")
+	sendKey(termbox.KeyEnter)
+	if ps := opened(); fmt.Sprint(ps) != zipped {
+		t.Errorf("unexpected columns %v", ps)
+	}
+
+	// esc cancels the reading, the dirs read after it are not opened
+	first, started, release := make(chan bool, 1), make(chan bool), make(chan bool)
+	first <- true
+	post(func() {
+		readDir = func(ctx context.Context, item model.FileItem, timeout time.Duration, progress func([]model.FileItem)) ([]model.FileItem, error) {
+			select {
+			case <-first:
+				started <- true
+				<-release
+			default:
+			}
+			return model.ReadDir(context.Background(), item, timeout, progress)
+		}
+	})
+	waitLoop()
+	defer post(func() { readDir = model.ReadDir })
+	sendKeys("o" + filepath.Join(tmp, "a", "b"))
+	sendKey(termbox.KeyEnter)
+	<-started
+	sendKey(termbox.KeyEsc)
+	close(release)
+	if ps := opened(); fmt.Sprint(ps) != zipped {
+		t.Errorf("the canceled path is opened: %v", ps)
+	}
+
+	post(func() {
+		if ps := wo.Paths.List(); len(ps) != 2 || ps[0] != zp+"@zip:///sub" {
+			t.Errorf("unexpected recent paths %v", ps)
+		}
+	})
+	waitLoop()
+}
@@ -5,9 +5,10 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/jacokoo/fff/model"
 	"github.com/jacokoo/fff/ui"
 	termbox "github.com/nsf/termbox-go"
 	yaml "gopkg.in/yaml.v2"
@@ -20,18 +21,22 @@ binding:
     "ctrl-q": ActionQuit                  # quit fff
 
   # bindings for normal mode
+  # a key can run a command instead of an action, such as ":sort mtime desc"
   normal:
     "s":                                  # Prefix, Sort File
       "n": ActionSortByName               ; Sort By Name
       "m": ActionSortByMtime              ; Sort By MTime
       "s": ActionSortBySize               ; Sort By Size
+      "o": ":sort mtime asc               ; Sort By MTime, Oldest First"
     ".": ActionToggleHidden               # Toggle show hidden files
     "d": ActionToggleDetail               # Toggle show file details
+    "p": ActionTogglePreview              # Toggle show preview of selected item
     "j": ActionMoveDown                   # Move down
     "k": ActionMoveUp                     # Move up
     "l": ActionOpenFolderRight            # Open folder on right
     "h": ActionCloseFolderRight           # Go to parent folder
-    "enter": ActionOpenFile               # Open file
+    "enter": ActionOpenFile               # Open file by the openers
+    "O": ActionOpenWith                   # Choose a program to open file
     ",": ActionShift                      # Shift column
     "K": ActionMoveToFirst                # Move to first item
     "J": ActionMoveToLast                 # Move to last item
@@ -60,6 +65,7 @@ binding:
     "f": ActionStartFilter                # Filter
     "F": ActionClearFilter                # Clear filter
     "g": ActionRefresh                    # Refresh current dir
+    "esc": ActionCancelLoading            # Cancel loading dir
     "+": ActionNewDir                     # Create new dir in current dir
     "N": ActionNewFile                    # Create new file in current dir
     "R": ActionRename                     # Rename current file
@@ -73,10 +79,16 @@ binding:
     "v": ActionView                       # Run pager
     "?": ActionShowHelp                   # Show help
     "-": ActionGoBack                     # Go back to previous dir
+    "x": ActionHexView                    # View selected file in hex
+    "#": ActionChecksum                   # Checksum marked files or current file
+    ":": ActionCommandLine                # Run a command, such as :sort mtime desc
+    "o": ActionGoToPath                   # Go to a path, such as ~/src or /a/b.zip@zip:///dir
+    "ctrl-r": ActionReloadConfig          # Reload config, it is also reloaded when the file is changed
     "t":
       "c": ActionShowClipDetail           ; Show clip detail
       "t": ActionShowTaskDetail           ; Show task detail
       "d": ActionCloseTaskDetail          ; Close task detail
+      "h": ActionShowTaskHistory          ; Show task history
       "f": ActionFakeTask                 ; Fake task
 
   # bindings for jump mode
@@ -88,6 +100,24 @@ binding:
     "enter": ActionQuitInputMode
     "esc": ActionAbortInputMode
     "backspace": ActionInputDelete
+    "delete": ActionInputDeleteForward
+    "ctrl-w": ActionInputDeleteWord
+    "ctrl-u": ActionInputDeleteToStart
+    "ctrl-y": ActionInputPaste
+    "left": ActionInputLeft
+    "right": ActionInputRight
+    "ctrl-b": ActionInputLeft
+    "ctrl-f": ActionInputRight
+    "home": ActionInputHome
+    "end": ActionInputEnd
+    "ctrl-a": ActionInputHome
+    "ctrl-e": ActionInputEnd
+    "tab": ActionInputComplete
+    "up": ActionInputHistoryPrev
+    "down": ActionInputHistoryNext
+    "ctrl-p": ActionInputHistoryPrev
+    "ctrl-n": ActionInputHistoryNext
+    "ctrl-r": ActionInputHistorySearch
 
   clip:
     "w": ActionDeleteClipOnce             # Jump to delete clip once
@@ -96,6 +126,44 @@ binding:
   task:
     "w": ActionCancelTaskOnce             # Jump to cancel task once
     "W": ActionCancelTask                 # Jump to cancel task
+    "p": ActionPauseTaskOnce              # Jump to pause or resume task once
+    "P": ActionPauseTask                  # Jump to pause or resume task
+    "t": ActionThrottleTaskOnce           # Jump to limit the rate of task once
+
+  history:
+    "j": ActionHistoryDown                # Select next task
+    "k": ActionHistoryUp                  # Select previous task
+    "down": ActionHistoryDown             # Select next task
+    "up": ActionHistoryUp                 # Select previous task
+    "r": ActionHistoryRetry               # Retry the failed items of selected task
+    "q": ActionHistoryClose               # Close history view
+    "esc": ActionHistoryClose             # Close history view
+
+  sums:
+    "j": ActionSumsDown                   # Select next file
+    "k": ActionSumsUp                     # Select previous file
+    "down": ActionSumsDown                # Select next file
+    "up": ActionSumsUp                    # Select previous file
+    "w": ActionSumsWrite                  # Write the checksums to SHA256SUMS
+    "q": ActionSumsClose                  # Close checksum view
+    "esc": ActionSumsClose                # Close checksum view
+
+  hex:
+    "j": ActionHexDown                    # Scroll down a line
+    "k": ActionHexUp                      # Scroll up a line
+    "down": ActionHexDown                 # Scroll down a line
+    "up": ActionHexUp                     # Scroll up a line
+    "space": ActionHexPageDown            # Scroll down a page
+    "pagedown": ActionHexPageDown         # Scroll down a page
+    "b": ActionHexPageUp                  # Scroll up a page
+    "pageup": ActionHexPageUp             # Scroll up a page
+    "g": ActionHexTop                     # Go to the start of the file
+    "G": ActionHexBottom                  # Go to the end of the file
+    "o": ActionHexGoto                    # Go to offset
+    "/": ActionHexSearch                  # Search text, or bytes like x:7f454c46
+    "n": ActionHexSearchNext              # Search next
+    "q": ActionHexClose                   # Cancel search or close hex view
+    "esc": ActionHexClose                 # Cancel search or close hex view
 
 color:
   normal: default
@@ -110,11 +178,63 @@ color:
   filter: magenta
   indicator: green
   clip: yellow
+  code-keyword: magenta
+  code-string: green
+  code-comment: blue
+  code-number: yellow
+  code-type: cyan
+  code-key: cyan
 
 editor: vi
 shell: sh
 pager: less
 single-column-mode: false
+preview: false
+load-timeout: 30
+watch: true
+watch-poll: 0
+
+# bytes per second of copies, like 512K or 10M, 0 for no limit
+# all limits every copy, the others limit the copies through the loader: file, ssh, zip, tar or tgz
+rate-limit:
+  all: 0
+  ssh: 0
+
+# compare the copied files with their sources by sha256
+verify-copy: false
+
+# programs opening files by enter, the rules are tried in order and the first program of the first matched rule is used
+# O lists the programs of all matched rules. a rule matches if all of its conditions match: ext the extensions,
+# glob the patterns of the name, mime the types detected from the content such as image/* or text/plain,
+# loader file, ssh, zip, tar or tgz. a program is run by the shell with the file appended, or in place of {file}
+# background: true runs the programs out of the terminal, otherwise fff waits for them like the editor
+# a file in an archive or on a ssh server is copied to a temp dir first. the system opener is used if no rule matches
+# openers:
+#   - ext: [jpg, png, gif]
+#     programs: [feh, gimp]
+#     background: true
+#   - mime: text/*
+#     programs: [vi, less]
+#   - glob: [Makefile, "*.mk"]
+#     programs: ["make -f {file}"]
+
+# commands run by the shell, in the current dir if it is a local dir
+# the placeholders are replaced by the quoted paths: {file} the selected file, {dir} the current dir,
+# {marked} the marked or selected files, {clip} the clipped files and {tabs} the paths of the tabs
+# mode: foreground runs in the terminal (default), background runs as a task with its output in the task history,
+# silent drops the output. wait: true asks for enter before going back to fff, for foreground only
+# key binds the command in normal mode, and :run NAME runs it from the command line
+# commands:
+#   test:
+#     run: go test ./...
+#     mode: background
+#     key: T
+#   code:
+#     run: code {marked}
+#     mode: silent
+#   diff:
+#     run: diff -u {marked}
+#     wait: true
 `)
 
 var colorMap = map[string]termbox.Attribute{
@@ -135,11 +255,22 @@ type config struct {
 	inputKbds        []*cmd
 	clipKbds         []*cmd
 	taskKbds         []*cmd
+	hexKbds          []*cmd
+	historyKbds      []*cmd
+	sumsKbds         []*cmd
 	colors           map[string]*ui.Color
 	editor           string
 	shell            string
 	pager            string
 	singleColumnMode bool
+	preview          bool
+	loadTimeout      time.Duration
+	watch            bool
+	watchPoll        time.Duration
+	rateLimits       map[string]int64
+	verifyCopy       bool
+	userCommands     map[string]*userCommand
+	openers          []*opener
 }
 
 func (c *config) color(name string) *ui.Color {
@@ -171,17 +302,113 @@ func readColors(ds interface{}, cfg *config) {
 	}
 }
 
+// readRateLimits read the limits of loaders, all is the limit of every copy
+func readRateLimits(ds interface{}, cfg *config) {
+	dd, suc := ds.(map[interface{}]interface{})
+	if !suc {
+		return
+	}
+	for k, v := range dd {
+		rate, err := model.ParseRate(fmt.Sprintf("%v", v))
+		if err != nil {
+			continue
+		}
+		cfg.rateLimits[fmt.Sprintf("%v", k)] = rate
+	}
+}
+
+// applyCopy set the rate limits and the verifying of copies
+func (c *config) applyCopy() {
+	for k, v := range c.rateLimits {
+		if k == "all" {
+			k = ""
+		}
+		model.SetRateLimit(k, v)
+	}
+	model.SetVerifyCopy(c.verifyCopy)
+}
+
+// readUserCommands read the commands run by the shell, each command is bound to its key in normal mode
+// the commands without a script or with an unknown mode are skipped
+func readUserCommands(ds interface{}, cfg *config) {
+	dd, suc := ds.(map[interface{}]interface{})
+	if !suc {
+		return
+	}
+	for k, v := range dd {
+		vv, ok := v.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("%v", k)
+		run, _ := vv["run"].(string)
+		mode, _ := vv["mode"].(string)
+		key, _ := vv["key"].(string)
+		if mode == "" {
+			mode = runForeground
+		}
+		if run == "" || (mode != runForeground && mode != runBackground && mode != runSilent) {
+			continue
+		}
+
+		cfg.userCommands[name] = &userCommand{run, mode, key, vv["wait"] == true}
+		if isValidKey(key) {
+			cfg.normalKbds = append([]*cmd{newCmd(key, ":run "+escapeWord(name), nil)}, cfg.normalKbds...)
+		}
+	}
+}
+
+// readStrings a string or a list of strings
+func readStrings(ds interface{}) []string {
+	switch vv := ds.(type) {
+	case string:
+		return []string{vv}
+	case []interface{}:
+		re := make([]string, 0, len(vv))
+		for _, v := range vv {
+			re = append(re, fmt.Sprintf("%v", v))
+		}
+		return re
+	}
+	return nil
+}
+
+// readOpeners read the rules of opening files, the rules without programs are skipped
+// the openers of the user config replace the default ones
+func readOpeners(ds interface{}, cfg *config) {
+	dd, suc := ds.([]interface{})
+	if !suc {
+		return
+	}
+	cfg.openers = nil
+	for _, v := range dd {
+		vv, ok := v.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		o := &opener{readStrings(vv["ext"]), readStrings(vv["glob"]), readStrings(vv["mime"]), readStrings(vv["loader"]), nil, vv["background"] == true}
+		for _, p := range readStrings(vv["programs"]) {
+			if strings.TrimSpace(p) != "" {
+				o.programs = append(o.programs, p)
+			}
+		}
+		if len(o.programs) != 0 {
+			cfg.openers = append(cfg.openers, o)
+		}
+	}
+}
+
 func createCmd(key, action string) *cmd {
 	return newCmd(key, action, nil)
 }
 
+// readChildren read the bindings under a prefix key, the invalid ones are left out and reported by checkConfig
 func readChildren(ds map[interface{}]interface{}) []*cmd {
 	cds := make([]*cmd, 0, len(ds))
 	for k, v := range ds {
-		kk, e1 := k.(string)
-		vv, e2 := v.(string)
-		if !e1 || !e2 {
-			panic(fmt.Sprintf("key [%v] or action [%v] must be string", k, v))
+		kk, vv := fmt.Sprintf("%v", k), fmt.Sprintf("%v", v)
+		if _, ok := v.(string); !ok || !isValidKey(kk) {
+			continue
 		}
 		cmd := newCmd(kk, vv, nil)
 		idx := strings.Index(vv, ";")
@@ -203,13 +430,15 @@ func readBinding(ds interface{}) []*cmd {
 
 	for k, v := range dd {
 		kk := fmt.Sprintf("%v", k)
+		if !isValidKey(kk) {
+			continue
+		}
 		switch vv := v.(type) {
 		case string:
 			re = append(re, newCmd(kk, vv, nil))
 		case map[interface{}]interface{}:
 			cs := readChildren(vv)
 			re = append(re, newCmd(kk, "", cs))
-
 		}
 	}
 	return re
@@ -227,12 +456,18 @@ func readBindings(ds interface{}, cfg *config) {
 	cfg.inputKbds = append(all, cfg.inputKbds...)
 	cfg.clipKbds = append(all, cfg.clipKbds...)
 	cfg.taskKbds = append(all, cfg.taskKbds...)
+	cfg.hexKbds = append(all, cfg.hexKbds...)
+	cfg.historyKbds = append(all, cfg.historyKbds...)
+	cfg.sumsKbds = append(all, cfg.sumsKbds...)
 
 	cfg.normalKbds = append(readBinding(dd["normal"]), cfg.normalKbds...)
 	cfg.jumpKbds = append(readBinding(dd["jump"]), cfg.jumpKbds...)
 	cfg.inputKbds = append(readBinding(dd["input"]), cfg.inputKbds...)
 	cfg.clipKbds = append(readBinding(dd["clip"]), cfg.clipKbds...)
 	cfg.taskKbds = append(readBinding(dd["task"]), cfg.taskKbds...)
+	cfg.hexKbds = append(readBinding(dd["hex"]), cfg.hexKbds...)
+	cfg.historyKbds = append(readBinding(dd["history"]), cfg.historyKbds...)
+	cfg.sumsKbds = append(readBinding(dd["sums"]), cfg.sumsKbds...)
 }
 
 func readYaml(ds []byte, cfg *config) {
@@ -249,24 +484,64 @@ func readYaml(ds []byte, cfg *config) {
 	}
 
 	vv, has = mp["shell"]
-	if has {
-		cfg.shell = vv.(string)
+	if s, ok := vv.(string); has && ok && s != "" {
+		cfg.shell = s
 	}
 
 	vv, has = mp["editor"]
-	if has {
-		cfg.editor = vv.(string)
+	if s, ok := vv.(string); has && ok && s != "" {
+		cfg.editor = s
 	}
 
 	vv, has = mp["pager"]
-	if has {
-		cfg.pager = vv.(string)
+	if s, ok := vv.(string); has && ok && s != "" {
+		cfg.pager = s
 	}
 
 	vv, has = mp["single-column-mode"]
 	if has && vv == true {
 		cfg.singleColumnMode = true
 	}
+
+	vv, has = mp["preview"]
+	if has {
+		cfg.preview = vv == true
+	}
+
+	vv, has = mp["load-timeout"]
+	if sec, ok := vv.(int); has && ok && sec > 0 {
+		cfg.loadTimeout = time.Duration(sec) * time.Second
+	}
+
+	vv, has = mp["watch"]
+	if has {
+		cfg.watch = vv == true
+	}
+
+	vv, has = mp["watch-poll"]
+	if sec, ok := vv.(int); has && ok && sec >= 0 {
+		cfg.watchPoll = time.Duration(sec) * time.Second
+	}
+
+	vv, has = mp["rate-limit"]
+	if has {
+		readRateLimits(vv, cfg)
+	}
+
+	vv, has = mp["verify-copy"]
+	if has {
+		cfg.verifyCopy = vv == true
+	}
+
+	vv, has = mp["openers"]
+	if has {
+		readOpeners(vv, cfg)
+	}
+
+	vv, has = mp["commands"]
+	if has {
+		readUserCommands(vv, cfg)
+	}
 }
 
 func (c *config) cmd(args string) *exec.Cmd {
@@ -274,10 +549,10 @@ func (c *config) cmd(args string) *exec.Cmd {
 }
 
 func initConfig() *config {
-	c := &config{colors: make(map[string]*ui.Color), shell: "", editor: "", pager: ""}
+	c := &config{colors: make(map[string]*ui.Color), shell: "", editor: "", pager: "", rateLimits: make(map[string]int64), userCommands: make(map[string]*userCommand)}
 	readYaml(data, c)
 
-	f, err := ioutil.ReadFile(filepath.Join(configDir, "config.yml"))
+	f, err := ioutil.ReadFile(userConfig())
 	if err == nil {
 		readYaml(f, c)
 	}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// options the options of command line
+type options struct {
+	help        bool
+	checkConfig bool
+	config      string
+	path        string
+}
+
+// parseArgs parse the command line, fff [--config FILE] [--check-config] [PATH]
+func parseArgs(args []string) (*options, error) {
+	opts := new(options)
+	for i := 0; i < len(args); i++ {
+		switch v := args[i]; {
+		case v == "-h" || v == "--help":
+			opts.help = true
+		case v == "--check-config":
+			opts.checkConfig = true
+		case v == "--config":
+			if i == len(args)-1 || args[i+1] == "" {
+				return nil, errors.New("--config needs a file")
+			}
+			i++
+			opts.config = args[i]
+		case strings.HasPrefix(v, "--config="):
+			if opts.config = v[len("--config="):]; opts.config == "" {
+				return nil, errors.New("--config needs a file")
+			}
+		case strings.HasPrefix(v, "-") && v != "-":
+			return nil, fmt.Errorf("unknown option %s", v)
+		case opts.path == "":
+			opts.path = v
+		default:
+			return nil, fmt.Errorf("unexpected argument %s", v)
+		}
+	}
+	return opts, nil
+}
+
+// xdgDir the fff dir in the base dir of env, or in def under home if env is not an absolute path
+func xdgDir(env, def string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "fff")
+	}
+	return filepath.Join(home, def, "fff")
+}
+
+// findConfigDir FFF_CONFIG_HOME, or fff in XDG_CONFIG_HOME
+func findConfigDir() string {
+	if dir := os.Getenv("FFF_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// findConfigFile the file given by --config, or config.yml in the config dir
+// the one in the legacy dir is used if there is no such file and the dir is not given by FFF_CONFIG_HOME
+func findConfigFile() string {
+	if args != nil && args.config != "" {
+		return args.config
+	}
+
+	file := filepath.Join(configDir, "config.yml")
+	if _, err := os.Stat(file); os.IsNotExist(err) && os.Getenv("FFF_CONFIG_HOME") == "" {
+		legacy := filepath.Join(legacyDir, "config.yml")
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+	return file
+}
+
+// dataFiles the files in the data dir, they were in the legacy dir
+var dataFiles = []string{"bookmarks", "views", "paths", "inputs", "transfers"}
+
+// rename move a data file, tests replace it to make a move fail
+var rename = os.Rename
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// migrateData move the data files in the legacy dir to the data dir, the files already in the data dir are kept
+// it returns the dir to use, the legacy dir if the files can not be moved, such as the data dir is read only
+// if some of the files fail to move, the moved ones are moved back so that the files are kept together. but the data
+// dir is used if it already has some of the files or a file can not be moved back, the error tells the files left
+func migrateData(legacy, data string) (string, error) {
+	if legacy == data {
+		return data, nil
+	}
+
+	olds, used := make([]string, 0), false
+	for _, v := range dataFiles {
+		switch {
+		case exists(filepath.Join(data, v)):
+			used = true
+		case exists(filepath.Join(legacy, v)):
+			olds = append(olds, v)
+		}
+	}
+	if len(olds) == 0 {
+		return data, nil
+	}
+
+	if err := os.MkdirAll(data, 0755); err != nil {
+		return legacy, nil
+	}
+
+	moved, left := make([]string, 0), make([]string, 0)
+	for _, v := range olds {
+		if err := rename(filepath.Join(legacy, v), filepath.Join(data, v)); err != nil {
+			left = append(left, v)
+		} else {
+			moved = append(moved, v)
+		}
+	}
+	if len(left) == 0 {
+		return data, nil
+	}
+
+	if !used {
+		back := 0
+		for _, v := range moved {
+			if err := rename(filepath.Join(data, v), filepath.Join(legacy, v)); err == nil {
+				left = append(left, v)
+				back++
+			}
+		}
+		if back == len(moved) {
+			return legacy, nil
+		}
+	}
+	return data, fmt.Errorf("%s can not be moved from %s to %s", strings.Join(left, ", "), legacy, data)
+}
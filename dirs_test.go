@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	for line, expect := range map[string]string{
+		"":                              "&{false false  }",
+		"docs":                          "&{false false  docs}",
+		"--config a.yml docs":           "&{false false a.yml docs}",
+		"--config=a.yml --check-config": "&{false true a.yml }",
+		"-h":                            "&{true false  }",
+		"--config":                      "--config needs a file",
+		"a b":                           "unexpected argument b",
+		"--nope":                        "unknown option --nope",
+	} {
+		opts, err := parseArgs(strings.Fields(line))
+		s := fmt.Sprint(opts)
+		if err != nil {
+			s = err.Error()
+		}
+		if s != expect || (opts == nil) != (err != nil) {
+			t.Errorf("%s: expect %s, got %s", line, expect, s)
+		}
+	}
+}
+
+func TestConfigDirs(t *testing.T) {
+	for _, v := range []string{"FFF_CONFIG_HOME", "XDG_CONFIG_HOME", "XDG_DATA_HOME"} {
+		defer os.Setenv(v, os.Getenv(v))
+	}
+
+	os.Setenv("FFF_CONFIG_HOME", "")
+	os.Setenv("XDG_CONFIG_HOME", "/etc/xdg")
+	os.Setenv("XDG_DATA_HOME", "relative")
+	if d := findConfigDir(); d != "/etc/xdg/fff" {
+		t.Errorf("expect the dir in XDG_CONFIG_HOME, got %s", d)
+	}
+	if d := xdgDir("XDG_DATA_HOME", ".local/share"); d != filepath.Join(home, ".local/share/fff") {
+		t.Errorf("expect a relative XDG_DATA_HOME is ignored, got %s", d)
+	}
+	os.Setenv("FFF_CONFIG_HOME", "/opt/fff")
+	if d := findConfigDir(); d != "/opt/fff" {
+		t.Errorf("expect FFF_CONFIG_HOME, got %s", d)
+	}
+
+	// the data files are moved from the legacy dir, the ones already moved are kept
+	tmp := tempDir(t)
+	defer os.RemoveAll(tmp)
+	legacy, data := filepath.Join(tmp, "config"), filepath.Join(tmp, "share", "fff")
+	os.MkdirAll(filepath.Join(legacy, "inputs"), 0755)
+	os.MkdirAll(data, 0755)
+	for name, content := range map[string]string{"config/bookmarks": "old", "config/config.yml": "", "config/inputs/filter": "a", "config/views": "old", "share/fff/views": "new"} {
+		ioutil.WriteFile(filepath.Join(tmp, name), []byte(content), 0644)
+	}
+	if d, err := migrateData(legacy, data); d != data || err != nil {
+		t.Fatalf("expect the data dir, got %s %v", d, err)
+	}
+	for name, content := range map[string]string{"share/fff/bookmarks": "old", "share/fff/inputs/filter": "a", "share/fff/views": "new", "config/views": "old", "config/config.yml": ""} {
+		if bs, err := ioutil.ReadFile(filepath.Join(tmp, name)); err != nil || string(bs) != content {
+			t.Errorf("%s: expect %q, got %q %v", name, content, bs, err)
+		}
+	}
+
+	// the legacy dir is used if the data dir can not be created
+	ioutil.WriteFile(filepath.Join(legacy, "paths"), nil, 0644)
+	ioutil.WriteFile(filepath.Join(tmp, "file"), nil, 0644)
+	if d, err := migrateData(legacy, filepath.Join(tmp, "file", "fff")); d != legacy || err != nil {
+		t.Errorf("expect the legacy dir, got %s %v", d, err)
+	}
+
+	// the moved files are moved back if one of them fails to move, the legacy dir is used
+	rename = func(from, to string) error {
+		if from == filepath.Join(legacy, "paths") {
+			return os.ErrPermission
+		}
+		return os.Rename(from, to)
+	}
+	defer func() { rename = os.Rename }()
+	ioutil.WriteFile(filepath.Join(legacy, "transfers"), nil, 0644)
+	fresh := filepath.Join(tmp, "fresh")
+	if d, err := migrateData(legacy, fresh); d != legacy || err != nil {
+		t.Errorf("expect the legacy dir, got %s %v", d, err)
+	}
+	if !exists(filepath.Join(legacy, "transfers")) || exists(filepath.Join(fresh, "transfers")) {
+		t.Error("expect the moved file is moved back")
+	}
+
+	// the data dir already in use is kept, the file can not be moved is left in the legacy dir
+	d, err := migrateData(legacy, data)
+	if d != data || err == nil || !strings.HasPrefix(err.Error(), "paths can not be moved") {
+		t.Errorf("expect the data dir and the file left, got %s %v", d, err)
+	}
+	if !exists(filepath.Join(data, "transfers")) || !exists(filepath.Join(legacy, "paths")) {
+		t.Error("expect the moved file is kept in the data dir")
+	}
+}
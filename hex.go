@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+const hexHelp = "[j/k]scroll  [space/b]page  [g/G]top/bottom  [o]goto offset  [/]search  [n]next  [q]close"
+
+// hexViewer state of the hex view, the file is read in background
+// a running search or read owns the file until it ends, it holds fileLock meanwhile
+type hexViewer struct {
+	lock      *sync.Mutex
+	file      *model.HexFile
+	fileLock  *sync.Mutex
+	closeFile context.CancelFunc
+	offset    int64
+	match     int64
+	pattern   []byte
+	searching context.Context
+	stop      context.CancelFunc
+	reading   context.Context
+	stopRead  context.CancelFunc
+}
+
+var (
+	hexView = &hexViewer{new(sync.Mutex), nil, new(sync.Mutex), nil, 0, -1, nil, nil, nil, nil, nil}
+
+	hexGotoInputer   = newRecordedInput("OFFSET", "hex-offset", func(str string) { hexView.gotoOffset(str) })
+	hexSearchInputer = newRecordedInput("SEARCH", "hex-search", func(str string) { hexView.search(str) })
+)
+
+// open the selected file in background like openFile, esc cancels it
+// the file is opened with a context living until the viewer is closed
+func (h *hexViewer) open() {
+	fi, err := wo.CurrentGroup().Current().CurrentFile()
+	if err != nil {
+		return
+	}
+
+	cancelLoading()
+	ctx, cancel := context.WithCancel(context.Background())
+	loadCancel, loadGroup, loadColumn = cancel, wo.CurrentGroup(), nil
+	ui.MessageEvent.Send("Opening " + fi.Path() + ", press esc to cancel")
+
+	startJob(func() {
+		file, err := model.OpenHex(ctx, fi)
+		post(func() {
+			if ctx.Err() != nil {
+				if file != nil {
+					file.Close()
+				}
+				return
+			}
+			loadCancel, loadGroup = nil, nil
+
+			if err != nil {
+				cancel()
+				ui.MessageEvent.Send(err.Error())
+				return
+			}
+			if mode != ModeNormal {
+				cancel()
+				file.Close()
+				return
+			}
+			h.show(file, cancel)
+		})
+	})
+}
+
+func (h *hexViewer) show(file *model.HexFile, closeFile context.CancelFunc) {
+	h.lock.Lock()
+	h.file, h.closeFile, h.offset, h.match, h.pattern = file, closeFile, 0, -1, nil
+	h.lock.Unlock()
+
+	changeMode(ModeHex)
+	h.render(hexHelp)
+}
+
+// close the viewer, a running search is canceled first
+// the file is closed in background after the running read ends
+func (h *hexViewer) close() {
+	h.lock.Lock()
+	if h.searching != nil {
+		h.stop()
+		h.searching, h.stop = nil, nil
+		h.lock.Unlock()
+		return
+	}
+
+	if h.stopRead != nil {
+		h.stopRead()
+		h.reading, h.stopRead = nil, nil
+	}
+	file, closeFile, fileLock := h.file, h.closeFile, h.fileLock
+	h.file, h.closeFile = nil, nil
+	h.lock.Unlock()
+
+	if file != nil {
+		closeFile()
+		go func() {
+			fileLock.Lock()
+			defer fileLock.Unlock()
+			file.Close()
+		}()
+	}
+	changeMode(ModeNormal)
+	ui.HexEvent.Send(nil)
+}
+
+// maxOffset the offset of the last page
+func (h *hexViewer) maxOffset() int64 {
+	rows, perRow := ui.HexLayout()
+	size := h.file.Size()
+	last := (size+int64(perRow)-1)/int64(perRow) - int64(rows)
+	if last < 0 {
+		return 0
+	}
+	return last * int64(perRow)
+}
+
+func (h *hexViewer) moveTo(offset int64) {
+	_, perRow := ui.HexLayout()
+	offset -= offset % int64(perRow)
+	if max := h.maxOffset(); offset > max {
+		offset = max
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	h.offset = offset
+}
+
+// render read the visible bytes in background and send them to ui, a running read is canceled
+// the caller should not hold the lock
+func (h *hexViewer) render(msg string) {
+	h.lock.Lock()
+	if h.file == nil {
+		h.lock.Unlock()
+		return
+	}
+	if h.stopRead != nil {
+		h.stopRead()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h.reading, h.stopRead = ctx, cancel
+
+	rows, perRow := ui.HexLayout()
+	h.moveTo(h.offset)
+	file, fileLock := h.file, h.fileLock
+	data := &ui.HexData{
+		Name: file.Item().Name(), Size: file.Size(), Offset: h.offset,
+		Match: h.match, MatchLen: len(h.pattern),
+	}
+	h.lock.Unlock()
+
+	startJob(func() {
+		fileLock.Lock()
+		buf := make([]byte, rows*perRow)
+		n, err := file.ReadAtContext(ctx, buf, data.Offset)
+		fileLock.Unlock()
+
+		data.Data = buf[:n]
+		post(func() { h.rendered(ctx, data, err, msg) })
+	})
+}
+
+// rendered show the bytes read in the state loop, they are dropped if another read started after
+func (h *hexViewer) rendered(ctx context.Context, data *ui.HexData, err error, msg string) {
+	h.lock.Lock()
+	current := h.reading == ctx
+	if current {
+		h.stopRead()
+		h.reading, h.stopRead = nil, nil
+	}
+	h.lock.Unlock()
+	if !current {
+		return
+	}
+
+	ui.HexEvent.Send(data)
+	if len(data.Data) == 0 && err != nil {
+		msg = err.Error()
+	}
+	if msg != "" {
+		ui.MessageEvent.Send(msg)
+	}
+}
+
+func (h *hexViewer) isSearching() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.searching != nil
+}
+
+func (h *hexViewer) scroll(rows int) {
+	if h.isSearching() {
+		return
+	}
+
+	_, perRow := ui.HexLayout()
+	h.lock.Lock()
+	h.moveTo(h.offset + int64(rows*perRow))
+	h.lock.Unlock()
+	h.render("")
+}
+
+func (h *hexViewer) page(n int) {
+	rows, _ := ui.HexLayout()
+	h.scroll(rows * n)
+}
+
+func (h *hexViewer) top() {
+	if h.isSearching() {
+		return
+	}
+
+	h.lock.Lock()
+	h.offset = 0
+	h.lock.Unlock()
+	h.render("")
+}
+
+func (h *hexViewer) bottom() {
+	if h.isSearching() {
+		return
+	}
+
+	h.lock.Lock()
+	h.offset = h.maxOffset()
+	h.lock.Unlock()
+	h.render("")
+}
+
+func (h *hexViewer) gotoOffset(str string) {
+	h.lock.Lock()
+	if h.file == nil || h.searching != nil {
+		h.lock.Unlock()
+		return
+	}
+
+	off, err := model.ParseOffset(str, h.offset)
+	if err != nil {
+		h.lock.Unlock()
+		h.render(err.Error())
+		return
+	}
+	h.moveTo(off)
+	h.lock.Unlock()
+	h.render(fmt.Sprintf("offset %08x", off))
+}
+
+func (h *hexViewer) search(str string) {
+	pattern, err := model.ParsePattern(str)
+	if err != nil {
+		h.render(err.Error())
+		return
+	}
+
+	h.lock.Lock()
+	h.pattern = pattern
+	h.match = -1
+	h.lock.Unlock()
+	h.searchFrom(h.offset)
+}
+
+func (h *hexViewer) searchNext() {
+	h.lock.Lock()
+	from := h.offset
+	if h.match != -1 {
+		from = h.match + 1
+	}
+	h.lock.Unlock()
+	h.searchFrom(from)
+}
+
+// searchFrom search the pattern in background, close the viewer to cancel it
+func (h *hexViewer) searchFrom(from int64) {
+	h.lock.Lock()
+	if h.file == nil || h.searching != nil || len(h.pattern) == 0 {
+		h.lock.Unlock()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.searching, h.stop = ctx, cancel
+	file, fileLock, pattern := h.file, h.fileLock, h.pattern
+	h.lock.Unlock()
+
+	ui.MessageEvent.Send(fmt.Sprintf("searching from %08x, press q to cancel", from))
+	startJob(func() {
+		fileLock.Lock()
+		off, err := file.Search(ctx, pattern, from)
+		fileLock.Unlock()
+		post(func() { h.searched(ctx, pattern, from, off, err) })
+	})
+}
+
+// searched show the search result in the state loop
+func (h *hexViewer) searched(ctx context.Context, pattern []byte, from, off int64, err error) {
+	h.lock.Lock()
+	if h.searching == ctx {
+		h.stop()
+		h.searching, h.stop = nil, nil
+	}
+	msg := ""
+	switch {
+	case err == model.ErrNotFound:
+		msg = fmt.Sprintf("%q not found after %08x", pattern, from)
+	case err != nil:
+		msg = err.Error()
+	default:
+		h.match = off
+		h.moveTo(off)
+		msg = fmt.Sprintf("found at %08x", off)
+	}
+	h.lock.Unlock()
+	h.render(msg)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+const historyHelp = "[j/k]select  [r]retry failed items  [q]close"
+
+// historyViewer state of the history view, the records are taken when it is opened
+type historyViewer struct {
+	records  []*model.TaskRecord
+	selected int
+}
+
+var historyView = new(historyViewer)
+
+func (h *historyViewer) open() {
+	h.records = wo.Tm.History()
+	if len(h.records) == 0 {
+		ui.MessageEvent.Send("No finished tasks")
+		return
+	}
+
+	h.selected = 0
+	changeMode(ModeHistory)
+	h.render(historyHelp)
+}
+
+func (h *historyViewer) close() {
+	h.records = nil
+	changeMode(ModeNormal)
+	ui.HistoryEvent.Send(nil)
+}
+
+func (h *historyViewer) render(msg string) {
+	ui.HistoryEvent.Send(&ui.HistoryData{Records: h.records, Selected: h.selected})
+	if msg != "" {
+		ui.MessageEvent.Send(msg)
+	}
+}
+
+func (h *historyViewer) move(n int) {
+	h.selected += n
+	if h.selected >= len(h.records) {
+		h.selected = len(h.records) - 1
+	}
+	if h.selected < 0 {
+		h.selected = 0
+	}
+	h.render("")
+}
+
+// retry submit the failed items of the selected task, the view is closed to show the progress
+func (h *historyViewer) retry() {
+	r := h.records[h.selected]
+	task := r.Retry()
+	if task == nil {
+		ui.MessageEvent.Send("Nothing to retry")
+		return
+	}
+
+	h.close()
+	submitTask(task)
+	ui.MessageEvent.Send(fmt.Sprintf("Retrying the failed items of %s", r.Task().Name()))
+}
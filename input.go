@@ -5,39 +5,56 @@ import (
 
 	"github.com/jacokoo/fff/model"
 	"github.com/jacokoo/fff/ui"
-	"github.com/mattn/go-runewidth"
 )
 
 var (
-	input     = make(chan rune)
-	inputQuit = make(chan bool)
-	inputer   Inputer
+	inputer Inputer
+	editor  = newLineEditor()
+
+	// the mode to return to after input
+	inputBkMode = ModeNormal
+
+	// browseIndex the history entry in the line while browsing the history, -1 for the typed line
+	browseIndex = -1
+	browseLine  string
 )
 
-// Inputer for input
+// Inputer for input, the line is edited in input mode and set to the inputer on each change
 type Inputer interface {
 	Name() string
 	Get() string
-	Append(ch rune)
-	Delete() bool
+	Set(string)
 	End(bool)
 }
 
+// secret an inputer whose value is shown masked
+type secret interface {
+	IsSecret() bool
+}
+
+// recorded an inputer whose inputs are kept in the history of its kind
+type recorded interface {
+	Kind() string
+}
+
 type nameInputer struct {
 	title  string
 	name   string
+	kind   string
 	action func(string)
 }
 
-func deleteLastChar(str string) (string, int) {
-	chs := []rune(str)
-	ch := chs[len(chs)-1]
-	width := runewidth.RuneWidth(ch)
-	return string(chs[:len(chs)-1]), width
+func newNameInput(title string, action func(string)) *nameInputer {
+	return &nameInputer{title, "", "", action}
 }
 
-func newNameInput(title string, action func(string)) *nameInputer {
-	return &nameInputer{title, "", action}
+// newRecordedInput create a name input whose inputs are kept in the history of kind
+func newRecordedInput(title, kind string, action func(string)) *nameInputer {
+	return &nameInputer{title, "", kind, action}
+}
+
+func (n *nameInputer) Kind() string {
+	return n.kind
 }
 
 func (n *nameInputer) Name() string {
@@ -48,28 +65,16 @@ func (n *nameInputer) Get() string {
 	return n.name
 }
 
-func (n *nameInputer) Append(ch rune) {
-	n.name += string(ch)
-}
-
-func (n *nameInputer) Delete() bool {
-	if len(n.name) == 0 {
-		return false
-	}
-
-	nn, _ := deleteLastChar(n.name)
-	n.name = nn
-	return true
+func (n *nameInputer) Set(name string) {
+	n.name = name
 }
 
 func (n *nameInputer) End(abort bool) {
-	if len(n.name) == 0 {
-		return
-	}
-	if !abort {
-		go n.action(n.name)
-	}
+	name := n.name
 	n.name = ""
+	if !abort && len(name) != 0 {
+		n.action(name)
+	}
 }
 
 type columnInputer struct {
@@ -80,68 +85,102 @@ func (co *columnInputer) Name() string {
 	return "FILTER"
 }
 
+func (co *columnInputer) Kind() string {
+	return "filter"
+}
+
 func (co *columnInputer) Get() string {
 	return co.Filter()
 }
 
-func (co *columnInputer) Append(ch rune) {
-	fi := co.Filter() + string(ch)
-	co.SetFilter(fi)
+func (co *columnInputer) Set(filter string) {
+	co.SetFilter(filter)
 	co.Update()
 	ui.ColumnContentChangeEvent.Send(co.Column)
 }
 
-func (co *columnInputer) Delete() bool {
-	f := co.Filter()
-	if len(f) == 0 {
-		return false
-	}
-
-	nn, _ := deleteLastChar(f)
-	co.SetFilter(nn)
-	co.Update()
-	ui.ColumnContentChangeEvent.Send(co.Column)
-	return true
+func (co *columnInputer) End(abort bool) {
+	wo.Views.Save(co.Column)
 }
 
-func (co *columnInputer) End(abort bool) {
+// inputEdit apply fn to the line, then pass the line to inputer
+func inputEdit(fn func(*lineEditor)) {
+	cancelCompletion()
+	browseIndex = -1
+	fn(editor)
+	if v := editor.String(); v != inputer.Get() {
+		inputer.Set(v)
+	}
+	sendInputChange()
 }
 
-func handleInputKey() {
-	for {
-		select {
-		case ch := <-input:
-			inputer.Append(ch)
-			ui.InputChangeEvent.Send([]string{inputer.Name(), inputer.Get()})
-		case <-inputQuit:
-			return
-		}
+func sendInputChange() {
+	value := editor.String()
+	if s, ok := inputer.(secret); ok && s.IsSecret() {
+		value = strings.Repeat("*", len(editor.line))
 	}
+	from, to := editor.selection()
+	ui.InputChangeEvent.Send(&ui.InputData{Name: inputer.Name(), Value: value, Cursor: editor.cursor, SelFrom: from, SelTo: to})
+}
+
+func inputAppend(ch rune) {
+	inputEdit(func(e *lineEditor) { e.insert([]rune{ch}) })
 }
 
 func enterInputMode(in Inputer) {
+	if mode != ModeInput {
+		inputBkMode = mode
+	}
 	changeMode(ModeInput)
 	inputer = in
-	ui.InputChangeEvent.Send([]string{inputer.Name(), inputer.Get()})
-	go handleInputKey()
+	editor.reset(in.Get())
+	browseIndex = -1
+	sendInputChange()
 }
 
+// quitInputMode restore the previous mode, then end the input
 func quitInputMode(abort bool) {
-	inputer.End(abort)
+	cancelCompletion()
+	in := inputer
 	inputer = nil
+	if h := inputHistory(in); h != nil && !abort {
+		h.Add(editor.String())
+	}
 	ui.QuitInputEvent.Send(wo.CurrentGroup().Current())
-	inputQuit <- true
-	changeMode(ModeNormal)
+	changeMode(inputBkMode)
+	if mode == ModeHex {
+		hexView.render("")
+	}
+	in.End(abort)
+	updatePreview()
 }
 
+// inputDelete delete backward, the input is aborted if the line is empty
 func inputDelete() {
-	b := inputer.Delete()
-	if !b {
+	if editor.isEmpty() {
 		quitInputMode(true)
 		return
 	}
+	inputEdit((*lineEditor).backspace)
+}
+
+// startRename input the new name of the current file, starting with its name with the stem selected
+func startRename() {
+	fi, err := wo.CurrentGroup().Current().CurrentFile()
+	if err != nil {
+		ui.MessageEvent.Send("no file selected")
+		return
+	}
 
-	ui.InputChangeEvent.Send([]string{inputer.Name(), inputer.Get()})
+	name := fi.Name()
+	stem := len([]rune(name))
+	if i := strings.LastIndex(name, "."); i > 0 && !fi.IsDir() {
+		stem = len([]rune(name[:i]))
+	}
+	renameInputer.Set(name)
+	enterInputMode(renameInputer)
+	editor.selectRange(0, stem)
+	sendInputChange()
 }
 
 type requestHandler struct {
@@ -149,19 +188,83 @@ type requestHandler struct {
 	*nameInputer
 }
 
-func (rh *requestHandler) Get() string {
-	if rh.isPassword {
-		return strings.Repeat("*", len(rh.name))
+func (rh *requestHandler) IsSecret() bool {
+	return rh.isPassword
+}
+
+// End always answer the request, the asker is blocked until then
+func (rh *requestHandler) End(abort bool) {
+	name := rh.name
+	rh.name = ""
+	if abort {
+		name = ""
 	}
-	return rh.name
+	rh.action(name)
 }
 
-func handleUserRequest() {
-	for {
-		req := <-model.RequestCh
-		rh := &requestHandler{req.IsPassword, &nameInputer{req.Title, "", func(end string) {
-			model.ResponseCh <- end
-		}}}
-		enterInputMode(rh)
+func askUser(title string, isPassword bool, answer func(string)) {
+	enterInputMode(&requestHandler{isPassword, newNameInput(title, answer)})
+}
+
+// inputHistory the history of in, nil if its inputs are not kept
+func inputHistory(in Inputer) *model.Recent {
+	if r, ok := in.(recorded); ok && r.Kind() != "" {
+		return wo.Inputs(r.Kind())
+	}
+	return nil
+}
+
+// inputBrowse replace the line with the nth older entry of the history, the typed line is back after the newest one
+func inputBrowse(n int) {
+	h := inputHistory(inputer)
+	if h == nil {
+		return
+	}
+	items := h.List()
+	idx := browseIndex + n
+	if idx < -1 || idx >= len(items) {
+		return
+	}
+
+	cancelCompletion()
+	if browseIndex == -1 {
+		browseLine = editor.String()
+	}
+	browseIndex = idx
+	line := browseLine
+	if idx != -1 {
+		line = items[idx]
+	}
+	editor.reset(line)
+	if line != inputer.Get() {
+		inputer.Set(line)
+	}
+	sendInputChange()
+}
+
+// inputSearchHistory show the entries of the history containing the line as the candidates,
+// the next search selects the next one
+func inputSearchHistory() {
+	if completing != nil {
+		inputComplete()
+		return
+	}
+	h := inputHistory(inputer)
+	if h == nil {
+		return
+	}
+
+	query := strings.ToLower(editor.String())
+	cs := make([]string, 0)
+	for _, v := range h.List() {
+		if strings.Contains(strings.ToLower(v), query) {
+			cs = append(cs, v)
+		}
+	}
+	if len(cs) == 0 {
+		return
 	}
+	cancelCompletion()
+	completing = &completion{cs, 0, ""}
+	completing.apply()
 }
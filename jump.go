@@ -1,9 +1,9 @@
 package main
 
 import (
-	"sync"
 	"unicode"
 
+	"github.com/jacokoo/fff/model"
 	"github.com/jacokoo/fff/ui"
 )
 
@@ -45,15 +45,15 @@ var (
 	cjumpDeleteClip     *JumpMode
 	jumpCancelTask      *JumpMode
 	cjumpCancelTask     *JumpMode
+	jumpPauseTask       *JumpMode
+	cjumpPauseTask      *JumpMode
+	jumpThrottleTask    *JumpMode
 )
 
 var (
-	jump      = make(chan rune)
-	jumpQuit  = make(chan bool)
 	jumpMode  *JumpMode
 	bkMode    Mode
 	jumpItems []*ui.JumpItem
-	mutex     = new(sync.Mutex)
 )
 
 func init() {
@@ -79,17 +79,42 @@ func init() {
 	jumpDeleteClip = &JumpMode{collectClip, nil}
 	cjumpDeleteClip = &JumpMode{collectClip, collectClip}
 
-	jumpCancelTask = &JumpMode{collectTask, nil}
-	cjumpCancelTask = &JumpMode{collectTask, collectTask}
-}
+	collectCancelTask := collectTask(func(t model.Task) { wo.Tm.Cancel(t) })
+	jumpCancelTask = &JumpMode{collectCancelTask, nil}
+	cjumpCancelTask = &JumpMode{collectCancelTask, collectCancelTask}
 
-func collectTask() []*ui.JumpItem {
-	return gui.Task.JumpItems(func(idx int) func() bool {
-		return func() bool {
-			wo.Tm.Cancel(wo.Tm.Tasks[idx])
-			return true
+	collectPauseTask := collectTask(func(t model.Task) {
+		if t.IsPaused() {
+			wo.Tm.Resume(t)
+			return
 		}
+		wo.Tm.Pause(t)
 	})
+	jumpPauseTask = &JumpMode{collectPauseTask, nil}
+	cjumpPauseTask = &JumpMode{collectPauseTask, collectPauseTask}
+
+	// the rate is asked after the jump mode is quit
+	jumpThrottleTask = &JumpMode{collectTask(func(t model.Task) {
+		go post(func() { enterInputMode(throttleInputer(t)) })
+	}), nil}
+}
+
+// collectTask the jump items of the running tasks, each item keeps the task shown when the items are collected,
+// so that a task finished or submitted meanwhile does not shift the others
+func collectTask(fn func(model.Task)) func() []*ui.JumpItem {
+	return func() []*ui.JumpItem {
+		tasks := wo.Tm.Tasks()
+		return gui.Task.JumpItems(func(idx int) func() bool {
+			if idx >= len(tasks) {
+				return nil
+			}
+			task := tasks[idx]
+			return func() bool {
+				fn(task)
+				return true
+			}
+		})
+	}
 }
 
 func collectClip() []*ui.JumpItem {
@@ -200,40 +225,34 @@ func handleJumpResult(item *ui.JumpItem) {
 	changeMode(ModeJump)
 	jumpItems = items
 	ui.JumpRefreshEvent.Send(items)
+	updatePreview()
+	updateWatch()
 }
 
-func handleKeys() {
-	for {
-	sc:
-		select {
-		case ch := <-jump:
-			changeMode(ModeDisabled)
-			var got = false
-			for _, it := range jumpItems {
-				if len(it.Key) == 0 {
-					continue
-				}
-				if it.Key[0] != ch {
-					it.Key = nil
-					continue
-				}
-				if len(it.Key) == 1 {
-					go handleJumpResult(it)
-					break sc
-				}
-
-				it.Key = it.Key[1:]
-				got = true
-			}
-			if got {
-				ui.JumpRefreshEvent.Send(jumpItems)
-				changeMode(ModeJump)
-			} else {
-				go quitJumpMode()
-			}
-		case <-jumpQuit:
+func jumpKey(ch rune) {
+	changeMode(ModeDisabled)
+	var got = false
+	for _, it := range jumpItems {
+		if len(it.Key) == 0 {
+			continue
+		}
+		if it.Key[0] != ch {
+			it.Key = nil
+			continue
+		}
+		if len(it.Key) == 1 {
+			handleJumpResult(it)
 			return
 		}
+
+		it.Key = it.Key[1:]
+		got = true
+	}
+	if got {
+		ui.JumpRefreshEvent.Send(jumpItems)
+		changeMode(ModeJump)
+	} else {
+		quitJumpMode()
 	}
 }
 
@@ -247,22 +266,19 @@ func enterJumpMode(md *JumpMode) {
 	jumpMode = md
 	keyThem(jumpItems)
 	ui.JumpRefreshEvent.Send(jumpItems)
-	go handleKeys()
 	changeMode(ModeJump)
 }
 
 func quitJumpMode() {
-	mutex.Lock()
-	defer mutex.Unlock()
-
 	if mode != ModeJump && mode != ModeDisabled {
 		return
 	}
-	jumpQuit <- true
 	jumpItems = nil
 	jumpMode = nil
 	ui.JumpRefreshEvent.Send(jumpItems)
 	changeMode(bkMode)
+	updatePreview()
+	updateWatch()
 }
 
 func indexKey(idx uint) rune {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jacokoo/fff/ui"
 
@@ -21,6 +22,9 @@ const (
 	ModeHelp
 	ModeClip
 	ModeTask
+	ModeHex
+	ModeHistory
+	ModeSums
 	ModeDisabled
 )
 
@@ -132,14 +136,18 @@ var (
 		"ActionMoveToLast":         limit(ModeNormal, func() { ac.moveToLast() }),
 		"ActionOpenFolderRight":    limit(ModeNormal, func() { ac.openRight() }),
 		"ActionOpenFile":           limit(ModeNormal, func() { ac.openFile() }),
+		"ActionOpenWith":           limit(ModeNormal, startOpenWith),
+		"ActionReloadConfig":       limit(ModeNormal, reloadConfig),
 		"ActionCloseFolderRight":   limit(ModeNormal, func() { ac.closeRight() }),
 		"ActionShift":              limit(ModeNormal, func() { ac.shift() }),
 		"ActionToggleBookmark":     limit(ModeNormal, func() { ac.toggleBookmark() }),
+		"ActionTogglePreview":      limit(ModeNormal, func() { ac.togglePreview() }),
 		"ActionChangeGroup0":       limit(ModeNormal, func() { ac.changeGroup(0) }),
 		"ActionChangeGroup1":       limit(ModeNormal, func() { ac.changeGroup(1) }),
 		"ActionChangeGroup2":       limit(ModeNormal, func() { ac.changeGroup(2) }),
 		"ActionChangeGroup3":       limit(ModeNormal, func() { ac.changeGroup(3) }),
 		"ActionRefresh":            limit(ModeNormal, func() { ac.refresh() }),
+		"ActionCancelLoading":      limit(ModeNormal, func() { cancelLoading() }),
 		"ActionQuitJump":           limit(ModeJump, func() { quitJumpMode() }),
 		"ActionToggleMarkAll":      limit(ModeNormal, func() { ac.toggleMarkAll() }),
 		"ActionToggleMark":         limit(ModeNormal, func() { ac.toggleMark() }),
@@ -155,9 +163,23 @@ var (
 		"ActionQuitInputMode":      limit(ModeInput, func() { quitInputMode(false) }),
 		"ActionAbortInputMode":     limit(ModeInput, func() { quitInputMode(true) }),
 		"ActionInputDelete":        limit(ModeInput, func() { inputDelete() }),
+		"ActionInputDeleteForward": limit(ModeInput, func() { inputEdit((*lineEditor).deleteForward) }),
+		"ActionInputDeleteWord":    limit(ModeInput, func() { inputEdit((*lineEditor).deleteWord) }),
+		"ActionInputDeleteToStart": limit(ModeInput, func() { inputEdit((*lineEditor).deleteToStart) }),
+		"ActionInputPaste":         limit(ModeInput, func() { inputEdit((*lineEditor).paste) }),
+		"ActionInputLeft":          limit(ModeInput, func() { inputEdit((*lineEditor).left) }),
+		"ActionInputRight":         limit(ModeInput, func() { inputEdit((*lineEditor).right) }),
+		"ActionInputHome":          limit(ModeInput, func() { inputEdit((*lineEditor).home) }),
+		"ActionInputEnd":           limit(ModeInput, func() { inputEdit((*lineEditor).end) }),
+		"ActionInputComplete":      limit(ModeInput, inputComplete),
+		"ActionInputHistoryPrev":   limit(ModeInput, func() { inputBrowse(1) }),
+		"ActionInputHistoryNext":   limit(ModeInput, func() { inputBrowse(-1) }),
+		"ActionInputHistorySearch": limit(ModeInput, inputSearchHistory),
+		"ActionGoToPath":           limit(ModeNormal, func() { enterInputMode(newGotoInput(wo.Paths)) }),
+		"ActionCommandLine":        limit(ModeNormal, func() { enterInputMode(commandLineInputer) }),
 		"ActionNewFile":            limit(ModeNormal, func() { enterInputMode(newFileInputer) }),
 		"ActionNewDir":             limit(ModeNormal, func() { enterInputMode(newDirInputer) }),
-		"ActionRename":             limit(ModeNormal, func() { enterInputMode(renameInputer) }),
+		"ActionRename":             limit(ModeNormal, startRename),
 		"ActionAddBookmark":        limit(ModeNormal, func() { enterInputMode(addBookmarkInputer) }),
 		"ActionDeleteBookmarkOnce": limit(ModeNormal, func() { enterJumpMode(jumpDeleteBookmark) }),
 		"ActionDeleteBookmark":     limit(ModeNormal, func() { enterJumpMode(cjumpDeleteBookmark) }),
@@ -174,7 +196,31 @@ var (
 		"ActionCloseTaskDetail":    limit(ModeTask, func() { ac.closeTaskDetail() }),
 		"ActionCancelTaskOnce":     limit(ModeTask, func() { enterJumpMode(jumpCancelTask) }),
 		"ActionCancelTask":         limit(ModeTask, func() { enterJumpMode(cjumpCancelTask) }),
+		"ActionPauseTaskOnce":      limit(ModeTask, func() { enterJumpMode(jumpPauseTask) }),
+		"ActionPauseTask":          limit(ModeTask, func() { enterJumpMode(cjumpPauseTask) }),
+		"ActionThrottleTaskOnce":   limit(ModeTask, func() { enterJumpMode(jumpThrottleTask) }),
 		"ActionFakeTask":           limit(ModeNormal, func() { ac.fakeTask() }),
+		"ActionShowTaskHistory":    limit(ModeNormal, func() { historyView.open() }),
+		"ActionHistoryClose":       limit(ModeHistory, func() { historyView.close() }),
+		"ActionHistoryDown":        limit(ModeHistory, func() { historyView.move(1) }),
+		"ActionHistoryUp":          limit(ModeHistory, func() { historyView.move(-1) }),
+		"ActionHistoryRetry":       limit(ModeHistory, func() { historyView.retry() }),
+		"ActionChecksum":           limit(ModeNormal, func() { sumsView.start() }),
+		"ActionSumsClose":          limit(ModeSums, func() { sumsView.close() }),
+		"ActionSumsDown":           limit(ModeSums, func() { sumsView.move(1) }),
+		"ActionSumsUp":             limit(ModeSums, func() { sumsView.move(-1) }),
+		"ActionSumsWrite":          limit(ModeSums, func() { sumsView.write() }),
+		"ActionHexView":            limit(ModeNormal, func() { hexView.open() }),
+		"ActionHexClose":           limit(ModeHex, func() { hexView.close() }),
+		"ActionHexDown":            limit(ModeHex, func() { hexView.scroll(1) }),
+		"ActionHexUp":              limit(ModeHex, func() { hexView.scroll(-1) }),
+		"ActionHexPageDown":        limit(ModeHex, func() { hexView.page(1) }),
+		"ActionHexPageUp":          limit(ModeHex, func() { hexView.page(-1) }),
+		"ActionHexTop":             limit(ModeHex, func() { hexView.top() }),
+		"ActionHexBottom":          limit(ModeHex, func() { hexView.bottom() }),
+		"ActionHexGoto":            limit(ModeHex, func() { enterInputMode(hexGotoInputer) }),
+		"ActionHexSearch":          limit(ModeHex, func() { enterInputMode(hexSearchInputer) }),
+		"ActionHexSearchNext":      limit(ModeHex, func() { hexView.searchNext() }),
 
 		"ActionDeleteFile": limit(ModeNormal, func() {
 			s := ac.deletePrompt()
@@ -185,23 +231,19 @@ var (
 			enterInputMode(deleteFileInputer)
 		}),
 
-		"ActionEdit":  limit(ModeNormal, func() { delay = func() error { return ac.edit() } }),
-		"ActionView":  limit(ModeNormal, func() { delay = func() error { return ac.view() } }),
-		"ActionShell": limit(ModeNormal, func() { delay = func() error { return ac.shell() } }),
+		"ActionEdit":  limit(ModeNormal, func() { delay = ac.edit() }),
+		"ActionView":  limit(ModeNormal, func() { delay = ac.view() }),
+		"ActionShell": limit(ModeNormal, func() { delay = ac.shell() }),
 	}
 
-	mode       = ModeNormal
-	kbd        = make(chan termbox.Event)
-	kbdQuit    = make(chan bool)
-	normalCh   = make(chan termbox.Event)
-	normalQuit = make(chan bool)
+	mode = ModeNormal
 
 	currentKbds        = cfg.normalKbds
 	keyPrefixed        = false
-	newFileInputer     = newNameInput("NEW FILE", func(name string) { ac.newFile(name) })
-	newDirInputer      = newNameInput("NEW DIR", func(name string) { ac.newDir(name) })
-	renameInputer      = newNameInput("RENAME", func(name string) { ac.rename(name) })
-	addBookmarkInputer = newNameInput("BOOKMARK NAME", func(name string) { ac.addBookmark(name, wo.CurrentGroup().Path()) })
+	newFileInputer     = newPathInput("NEW FILE", "new-file", func(name string) { ac.newFile(name) })
+	newDirInputer      = newPathInput("NEW DIR", "new-dir", func(name string) { ac.newDir(name) })
+	renameInputer      = newPathInput("RENAME", "rename", func(name string) { ac.rename(name) })
+	addBookmarkInputer = newRecordedInput("BOOKMARK NAME", "bookmark", func(name string) { ac.addBookmark(name, wo.CurrentGroup().Path()) })
 
 	deleteFileInputer = newNameInput("", func(name string) {
 		if name == "y" {
@@ -213,6 +255,9 @@ var (
 func changeMode(to Mode) {
 	mode = to
 	restoreKbds()
+	if reloadPending && to == ModeNormal {
+		go post(reloadIfPending)
+	}
 }
 
 func restoreKbds() {
@@ -232,6 +277,12 @@ func restoreKbds() {
 		currentKbds = cfg.clipKbds
 	case ModeTask:
 		currentKbds = cfg.taskKbds
+	case ModeHex:
+		currentKbds = cfg.hexKbds
+	case ModeHistory:
+		currentKbds = cfg.historyKbds
+	case ModeSums:
+		currentKbds = cfg.sumsKbds
 	default:
 		currentKbds = nil
 	}
@@ -257,12 +308,15 @@ func doAction(ev termbox.Event) bool {
 	}
 
 	if !c.prefix {
-		ac, has := actions[c.action]
-		if has {
+		if strings.HasPrefix(c.action, ":") {
+			runCommand(c.action[1:])
+		} else if ac, has := actions[c.action]; has {
 			ac()
 		}
 
 		restoreKbds()
+		updatePreview()
+		updateWatch()
 		return true
 	}
 
@@ -320,7 +374,7 @@ func kbdHandleNormal(ev termbox.Event) {
 
 func kbdHandleJump(ev termbox.Event) {
 	if ev.Ch != 0 {
-		jump <- ev.Ch
+		jumpKey(ev.Ch)
 		return
 	}
 	doAction(ev)
@@ -328,12 +382,12 @@ func kbdHandleJump(ev termbox.Event) {
 
 func kbdHandleInput(ev termbox.Event) {
 	if ev.Ch != 0 {
-		input <- ev.Ch
+		inputAppend(ev.Ch)
 		return
 	}
 
 	if ev.Key == termbox.KeySpace {
-		input <- ' '
+		inputAppend(' ')
 		return
 	}
 
@@ -352,47 +406,38 @@ func kbdHandleTask(ev termbox.Event) {
 	}
 }
 
-func handleKeyEvent() {
-	for {
-		select {
-		case ev := <-kbd:
-			switch mode {
-			case ModeInput:
-				kbdHandleInput(ev)
-			case ModeJump:
-				kbdHandleJump(ev)
-			case ModeNormal:
-				select {
-				case normalCh <- ev:
-				default:
-				}
-			case ModeHelp:
-				ac.closeHelp()
-				restoreKbds()
-			case ModeClip:
-				kbdHandleClip(ev)
-			case ModeTask:
-				kbdHandleTask(ev)
-			}
-		case <-kbdQuit:
-			normalQuit <- true
-			return
-		}
+// handleKey handle key events in the state loop
+func handleKey(ev termbox.Event) {
+	if stopping {
+		return
 	}
-}
 
-func handleNormalKbd() {
-	for {
-		select {
-		case ev := <-normalCh:
-			kbdHandleNormal(ev)
-		case <-normalQuit:
-			return
-		}
+	if isShell(ev) {
+		kbdHandleNormal(ev)
+		stopUI(2)
+		return
 	}
-}
 
-func kbdStart() {
-	go handleNormalKbd()
-	go handleKeyEvent()
+	if isQuit(ev) {
+		stopUI(1)
+		return
+	}
+
+	switch mode {
+	case ModeInput:
+		kbdHandleInput(ev)
+	case ModeJump:
+		kbdHandleJump(ev)
+	case ModeNormal:
+		kbdHandleNormal(ev)
+	case ModeHelp:
+		ac.closeHelp()
+		restoreKbds()
+	case ModeClip:
+		kbdHandleClip(ev)
+	case ModeTask:
+		kbdHandleTask(ev)
+	case ModeHex, ModeHistory, ModeSums:
+		doAction(ev)
+	}
 }
@@ -0,0 +1,154 @@
+package main
+
+import "unicode"
+
+// lineEditor the line being edited in input mode
+// the text between anchor and cursor is selected, it is replaced by the next input
+type lineEditor struct {
+	line   []rune
+	cursor int
+	anchor int // -1 if nothing is selected
+	killed []rune
+}
+
+func newLineEditor() *lineEditor {
+	return &lineEditor{nil, 0, -1, nil}
+}
+
+// reset the line to str, the cursor is at the end, the killed text is kept for paste
+func (e *lineEditor) reset(str string) {
+	e.line = []rune(str)
+	e.cursor = len(e.line)
+	e.anchor = -1
+}
+
+func (e *lineEditor) String() string {
+	return string(e.line)
+}
+
+func (e *lineEditor) isEmpty() bool {
+	return len(e.line) == 0
+}
+
+// selectRange select the runes in [from, to), the cursor is moved to to
+func (e *lineEditor) selectRange(from, to int) {
+	e.anchor, e.cursor = from, to
+}
+
+// selection the selected range, from == to if nothing is selected
+func (e *lineEditor) selection() (int, int) {
+	if e.anchor < 0 {
+		return e.cursor, e.cursor
+	}
+	if e.anchor < e.cursor {
+		return e.anchor, e.cursor
+	}
+	return e.cursor, e.anchor
+}
+
+// remove the runes in [from, to), the cursor is moved to from
+func (e *lineEditor) remove(from, to int) []rune {
+	rs := append([]rune(nil), e.line[from:to]...)
+	e.line = append(e.line[:from], e.line[to:]...)
+	e.cursor, e.anchor = from, -1
+	return rs
+}
+
+func (e *lineEditor) deleteSelection() bool {
+	from, to := e.selection()
+	e.anchor = -1
+	if from == to {
+		return false
+	}
+	e.remove(from, to)
+	return true
+}
+
+func (e *lineEditor) insert(rs []rune) {
+	e.deleteSelection()
+	line := make([]rune, 0, len(e.line)+len(rs))
+	line = append(line, e.line[:e.cursor]...)
+	line = append(line, rs...)
+	e.line = append(line, e.line[e.cursor:]...)
+	e.cursor += len(rs)
+}
+
+// backspace delete the selection or the rune before cursor
+func (e *lineEditor) backspace() {
+	if e.deleteSelection() || e.cursor == 0 {
+		return
+	}
+	e.remove(e.cursor-1, e.cursor)
+}
+
+// deleteForward delete the selection or the rune under cursor
+func (e *lineEditor) deleteForward() {
+	if e.deleteSelection() || e.cursor == len(e.line) {
+		return
+	}
+	e.remove(e.cursor, e.cursor+1)
+}
+
+func (e *lineEditor) left() {
+	from, to := e.selection()
+	e.anchor = -1
+	if from != to {
+		e.cursor = from
+		return
+	}
+	if e.cursor > 0 {
+		e.cursor--
+	}
+}
+
+func (e *lineEditor) right() {
+	from, to := e.selection()
+	e.anchor = -1
+	if from != to {
+		e.cursor = to
+		return
+	}
+	if e.cursor < len(e.line) {
+		e.cursor++
+	}
+}
+
+func (e *lineEditor) home() {
+	e.cursor, e.anchor = 0, -1
+}
+
+func (e *lineEditor) end() {
+	e.cursor, e.anchor = len(e.line), -1
+}
+
+// deleteWord delete the word before cursor like ctrl-w of shell, the spaces before cursor go with it
+func (e *lineEditor) deleteWord() {
+	if e.deleteSelection() {
+		return
+	}
+	i := e.cursor
+	for i > 0 && unicode.IsSpace(e.line[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(e.line[i-1]) {
+		i--
+	}
+	if i != e.cursor {
+		e.killed = e.remove(i, e.cursor)
+	}
+}
+
+// deleteToStart delete from the start of line to cursor
+func (e *lineEditor) deleteToStart() {
+	e.anchor = -1
+	if e.cursor != 0 {
+		e.killed = e.remove(0, e.cursor)
+	}
+}
+
+// paste the text deleted last by deleteWord or deleteToStart
+func (e *lineEditor) paste() {
+	if len(e.killed) != 0 {
+		e.insert(e.killed)
+	}
+}
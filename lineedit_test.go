@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+func TestLoopRename(t *testing.T) {
+	tmp := tempDir(t, "notes.txt")
+	defer os.RemoveAll(tmp)
+	startLoop(tmp)
+
+	// the stem is selected, typing replaces it and keeps the extension
+	sendKeys("Rtodo")
+	sendKey(termbox.KeyEnd)
+	for i := 0; i < 3; i++ {
+		sendKey(termbox.KeyBackspace2)
+	}
+	sendKeys("md")
+	sendKey(termbox.KeyCtrlA)
+	sendKeys("my-")
+	sendKey(termbox.KeyEnter)
+	waitLoop()
+
+	if _, err := os.Stat(filepath.Join(tmp, "my-todo.md")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoopInputHistory(t *testing.T) {
+	tmp := tempDir(t, "abc", "xyz")
+	defer os.RemoveAll(tmp)
+	startLoop(tmp)
+
+	filter := func() string {
+		ch := make(chan string)
+		post(func() { ch <- wo.CurrentGroup().Current().Filter() })
+		return <-ch
+	}
+
+	for _, v := range []string{"ab", "xy"} {
+		sendKeys("f" + v)
+		sendKey(termbox.KeyEnter)
+		sendKeys("F")
+	}
+
+	// up goes to the older one, down comes back to the typed line
+	sendKeys("fz")
+	sendKey(termbox.KeyArrowUp)
+	sendKey(termbox.KeyArrowUp)
+	sendKey(termbox.KeyArrowDown)
+	sendKey(termbox.KeyArrowDown)
+	if f := filter(); f != "z" {
+		t.Errorf("expect the typed filter z, got %s", f)
+	}
+	sendKey(termbox.KeyArrowUp)
+	sendKey(termbox.KeyArrowUp)
+	sendKey(termbox.KeyEnter)
+	if f := filter(); f != "ab" {
+		t.Errorf("expect filter ab from history, got %s", f)
+	}
+
+	// ctrl-r searches the entries containing the line
+	sendKeys("F")
+	sendKeys("fy")
+	sendKey(termbox.KeyCtrlR)
+	sendKey(termbox.KeyEnter)
+	if f := filter(); f != "xy" {
+		t.Errorf("expect filter xy from search, got %s", f)
+	}
+
+	bs, _ := ioutil.ReadFile(filepath.Join(tmp, ".config", "inputs", "filter"))
+	if string(bs) != "xy\nab\n" {
+		t.Errorf("unexpected saved history %q", bs)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+var (
+	// readDir read the items of a dir, tests replace it to control when a read is done
+	readDir = model.ReadDir
+
+	loadCancel context.CancelFunc
+	loadGroup  model.Group
+
+	// nil while the selected file is being opened as a dir
+	loadColumn model.Column
+)
+
+// openLoading open item in a loading column of gu, the items are read in background
+func openLoading(gu model.Group, item model.FileItem) {
+	gu.Record()
+	co := gu.OpenDirLoading(item)
+	if len(gu.Columns()) >= maxColumns {
+		gu.Shift()
+	}
+	ui.OpenRightEvent.Send(gu)
+	ui.MessageEvent.Send("Loading " + co.Path() + ", press esc to cancel")
+	loadDir(gu, co)
+}
+
+// openFile load fi as a dir in background, such as a .tar or .ssh.fff file
+// it is opened if fi is still selected when loaded, the password of ssh is asked in the state loop
+func openFile(gu model.Group, fi model.FileItem) {
+	cancelLoading()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	loadCancel, loadGroup, loadColumn = cancel, gu, nil
+	ui.MessageEvent.Send("Opening " + fi.Path() + ", press esc to cancel")
+
+	startJob(func() {
+		item, err := model.LoadFile(ctx, fi)
+		post(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			cancel()
+			loadCancel, loadGroup = nil, nil
+
+			if err != nil {
+				ui.MessageEvent.Send("Can not open " + fi.Path() + ": " + err.Error())
+				return
+			}
+
+			cur, err := gu.Current().CurrentFile()
+			if wo.CurrentGroup() != gu || err != nil || cur.Path() != fi.Path() {
+				return
+			}
+			openLoading(gu, item)
+		})
+	})
+}
+
+// loadDir read items of the loading column co in background, the items are applied in the state loop
+// the result is dropped if the column is closed or another dir is opened before it finished
+func loadDir(gu model.Group, co model.Column) {
+	cancelLoading()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	loadCancel, loadGroup, loadColumn = cancel, gu, co
+
+	timeout := cfg.loadTimeout
+	startJob(func() {
+		items, err := readDir(ctx, co.File(), timeout, func(items []model.FileItem) {
+			post(func() {
+				if ctx.Err() == nil && gu.Contains(co) {
+					co.Loaded(items, true)
+					showLoaded(gu, co)
+				}
+			})
+		})
+
+		post(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			cancel()
+			loadCancel, loadGroup, loadColumn = nil, nil, nil
+
+			if !gu.Contains(co) {
+				return
+			}
+
+			if err != nil {
+				closeLoading(gu, co, "Can not read dir "+co.Path()+": "+err.Error())
+				return
+			}
+
+			co.Loaded(items, false)
+			showLoaded(gu, co)
+		})
+	})
+}
+
+func showLoaded(gu model.Group, co model.Column) {
+	if wo.CurrentGroup() == gu && gu.Current() == co {
+		ui.ColumnContentChangeEvent.Send(co)
+		ui.ChangeSelectEvent.Send(co)
+		updatePreview()
+		updateWatch()
+	}
+}
+
+// closeLoading close the loading column if it is still the current one
+func closeLoading(gu model.Group, co model.Column, msg string) {
+	if gu.Current() != co {
+		return
+	}
+
+	re, err := gu.CloseDir()
+	if wo.CurrentGroup() != gu {
+		return
+	}
+
+	if err == nil {
+		switch re {
+		case model.CloseSuccess:
+			ui.CloseRightEvent.Send(gu.Current())
+		case model.CloseToParent:
+			ui.ToParentEvent.Send(gu.Current())
+		}
+	}
+	ui.MessageEvent.Send(msg)
+	updatePreview()
+}
+
+// cancelLoading cancel the running open or dir read, the loading column is closed
+func cancelLoading() {
+	if loadCancel == nil {
+		return
+	}
+	loadCancel()
+	gu, co := loadGroup, loadColumn
+	loadCancel, loadGroup, loadColumn = nil, nil, nil
+
+	if co == nil {
+		ui.MessageEvent.Send("Open canceled")
+		return
+	}
+	closeLoading(gu, co, model.ErrLoadCanceled.Error())
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+func TestLoopLoadCanceled(t *testing.T) {
+	tmp := tempDir(t)
+	defer os.RemoveAll(tmp)
+	os.MkdirAll(filepath.Join(tmp, "a"), 0755)
+	ioutil.WriteFile(filepath.Join(tmp, "a", "x"), nil, 0644)
+	startLoop(tmp)
+
+	// each read waits to be released, the items are sent as progress too
+	reads := make(chan chan bool)
+	post(func() {
+		readDir = func(ctx context.Context, item model.FileItem, timeout time.Duration, progress func([]model.FileItem)) ([]model.FileItem, error) {
+			release := make(chan bool)
+			reads <- release
+			<-release
+			items, err := model.ReadDir(context.Background(), item, timeout, nil)
+			progress(items)
+			return items, err
+		}
+	})
+	waitLoop()
+	defer post(func() { readDir = model.ReadDir })
+
+	columns := func() string {
+		ch := make(chan string)
+		post(func() {
+			re := make([]string, 0)
+			for _, v := range wo.CurrentGroup().Columns() {
+				re = append(re, fmt.Sprintf("%s:%d", filepath.Base(v.Path()), len(v.Files())))
+			}
+			ch <- strings.Join(re, " ")
+		})
+		return <-ch
+	}
+	root := filepath.Base(tmp) + ":1"
+
+	sendKeys("l")
+	first := <-reads
+	if cs := columns(); cs != root+" a:0" {
+		t.Errorf("expect a loading column, got %s", cs)
+	}
+
+	// the first read is canceled by esc, its result comes while a is loaded again
+	sendKey(termbox.KeyEsc)
+	if cs := columns(); cs != root {
+		t.Errorf("the loading column is not closed: %s", cs)
+	}
+	sendKeys("l")
+	second := <-reads
+	close(first)
+	waitJobs(1)
+	if cs := columns(); cs != root+" a:0" {
+		t.Errorf("the canceled read is applied: %s", cs)
+	}
+
+	// the second read can still be canceled
+	sendKey(termbox.KeyEsc)
+	close(second)
+	waitJobs(0)
+	if cs := columns(); cs != root {
+		t.Errorf("the second read is not canceled: %s", cs)
+	}
+
+	post(func() { readDir = model.ReadDir })
+	waitLoop()
+	sendKeys("l")
+	waitJobs(0)
+	if cs := columns(); cs != root+" a:1" {
+		t.Errorf("a is not loaded: %s", cs)
+	}
+}
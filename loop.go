@@ -0,0 +1,106 @@
+package main
+
+import (
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// the workspace is owned by the state loop, keys, results of background jobs
+// and user requests are handled in it one by one, other goroutines post their changes to it
+
+var (
+	kbd     = make(chan termbox.Event)
+	stateCh = make(chan func(), 64)
+
+	// requests wait here until the current input is finished
+	requests []*model.Request
+
+	// startJob run a job in background, such as a read posting its result to the state loop
+	// tests replace it to wait until the jobs are done
+	startJob = func(job func()) { go job() }
+)
+
+// post run fn in the state loop, it should not be called by the loop itself
+func post(fn func()) {
+	stateCh <- fn
+}
+
+// serve handle keys, posted changes and user requests until done is closed
+func serve(done <-chan bool) {
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if mode != ModeInput && len(requests) > 0 {
+			req := requests[0]
+			requests = requests[1:]
+			askUser(req.Title, req.IsPassword, req.Answer)
+		}
+
+		select {
+		case <-done:
+			return
+		case ev := <-kbd:
+			runState(func() { handleKey(ev) })
+		case fn := <-stateCh:
+			runState(fn)
+		case req := <-model.RequestCh:
+			requests = append(requests, req)
+		}
+	}
+}
+
+// runState run fn as the owner of the state
+// if fn asks the user for something, it is blocked and only the keys of the answer are handled until it is
+// answered, the posted changes and other requests wait until fn returns, so nothing changes the state under fn
+func runState(fn func()) {
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	// requests of tasks are queued after fn returned, fn may be touching the state
+	var background []*model.Request
+	for {
+		select {
+		case <-done:
+			requests = append(requests, background...)
+			return
+		case req := <-model.RequestCh:
+			if req.IsBackground() {
+				background = append(background, req)
+				continue
+			}
+
+			// fn can not go on without the answer, so it takes the input from others
+			if mode == ModeInput {
+				quitInputMode(true)
+			}
+
+			answer := ""
+			answered := make(chan bool)
+			askUser(req.Title, req.IsPassword, func(str string) {
+				answer = str
+				close(answered)
+			})
+			serveAnswer(answered)
+			req.Answer(answer)
+		}
+	}
+}
+
+// serveAnswer handle the keys of the input until it is answered
+func serveAnswer(answered <-chan bool) {
+	for {
+		select {
+		case <-answered:
+			return
+		case ev := <-kbd:
+			runState(func() { handleKey(ev) })
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+	termbox "github.com/nsf/termbox-go"
+)
+
+var (
+	loopOnce  = new(sync.Once)
+	eventLock = new(sync.Mutex)
+	eventHook func(ui.Event)
+
+	jobLock    = new(sync.Mutex)
+	jobDone    = sync.NewCond(jobLock)
+	jobCount   int
+	jobStarted int
+)
+
+// hookEvents call fn with each ui event sent after, the events are dropped again if fn is nil
+func hookEvents(fn func(ui.Event)) {
+	eventLock.Lock()
+	eventHook = fn
+	eventLock.Unlock()
+}
+
+// startLoop serve a workspace of dir without a terminal, the ui events are dropped unless they are hooked
+// the background jobs are counted, so that tests can wait for their results
+func startLoop(dir string) {
+	loopOnce.Do(func() {
+		go func() {
+			for ev := range ui.Gui {
+				eventLock.Lock()
+				if eventHook != nil {
+					eventHook(ev)
+				}
+				eventLock.Unlock()
+				ui.GuiAck <- true
+			}
+		}()
+		startJob = countJob
+		go serve(nil)
+	})
+
+	post(func() {
+		wo = model.NewWorkspace(maxGroups, dir, filepath.Join(dir, ".config"), false)
+		ac = newAction()
+		maxColumns = 3
+		changeMode(ModeNormal)
+	})
+	waitLoop()
+}
+
+func countJob(job func()) {
+	jobLock.Lock()
+	jobCount++
+	jobStarted++
+	jobLock.Unlock()
+
+	go func() {
+		defer func() {
+			jobLock.Lock()
+			jobCount--
+			jobDone.Broadcast()
+			jobLock.Unlock()
+		}()
+		job()
+	}()
+}
+
+// waitLoop wait until all changes posted before are handled
+func waitLoop() {
+	ch := make(chan bool)
+	post(func() { close(ch) })
+	<-ch
+}
+
+// waitJobs wait until the background jobs are done and their results are handled, the jobs started by the
+// results too. blocked is the number of jobs the test keeps blocking, they are not waited
+func waitJobs(blocked int) {
+	for {
+		jobLock.Lock()
+		for jobCount > blocked {
+			jobDone.Wait()
+		}
+		started := jobStarted
+		jobLock.Unlock()
+		waitLoop()
+
+		// no job is started by the handled results, so nothing is posted after them
+		jobLock.Lock()
+		idle := jobStarted == started
+		jobLock.Unlock()
+		if idle {
+			return
+		}
+	}
+}
+
+func sendKeys(str string) {
+	for _, v := range str {
+		kbd <- termbox.Event{Type: termbox.EventKey, Ch: v}
+	}
+}
+
+func sendKey(key termbox.Key) {
+	kbd <- termbox.Event{Type: termbox.EventKey, Key: key}
+}
+
+func tempDir(t *testing.T, files ...string) string {
+	tmp, err := ioutil.TempDir("", "fff-loop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range files {
+		ioutil.WriteFile(filepath.Join(tmp, v), []byte(v), 0644)
+	}
+	return tmp
+}
+
+// finishedTasks a channel receiving the tasks finished after
+func finishedTasks(size int) chan model.Task {
+	ch := make(chan model.Task, size)
+	post(func() {
+		wo.Tm.Attach(model.NewTaskListener(nil, func(task model.Task) { ch <- task }, nil))
+	})
+	waitLoop()
+	return ch
+}
+
+// waitTask wait for a task from finished, the test fails if none is finished in 5 seconds
+func waitTask(t *testing.T, finished chan model.Task) model.Task {
+	select {
+	case task := <-finished:
+		return task
+	case <-time.After(5 * time.Second):
+		t.Fatal("the task is not finished")
+	}
+	return nil
+}
+
+func TestLoopConcurrentChanges(t *testing.T) {
+	tmp := tempDir(t, "a", "b", "c")
+	defer os.RemoveAll(tmp)
+	startLoop(tmp)
+	finished := finishedTasks(20)
+
+	wg := new(sync.WaitGroup)
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			sendKeys("jjk")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			ioutil.WriteFile(filepath.Join(tmp, fmt.Sprintf("f%d", i)), nil, 0644)
+			post(ac.refresh)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			task := model.NewTask("task", func(ctx context.Context, progress chan<- int, err chan<- error) {
+				defer close(err)
+				defer close(progress)
+				for p := 0; p <= 10; p++ {
+					progress <- p
+				}
+			})
+			post(func() { wo.Tm.Submit(task) })
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			post(func() {
+				co := wo.CurrentGroup().Current()
+				co.ToggleMark()
+				wo.Clip = co.MarkedOrSelected()
+			})
+		}
+	}()
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		waitTask(t, finished)
+	}
+	post(func() {
+		if len(wo.Tm.Tasks()) != 0 {
+			t.Errorf("%d tasks are not finished", len(wo.Tm.Tasks()))
+		}
+		if n := len(wo.CurrentGroup().Current().Files()); n != 53 {
+			t.Errorf("expect 53 files after refresh, got %d", n)
+		}
+	})
+	waitLoop()
+}
+
+func TestLoopInput(t *testing.T) {
+	tmp := tempDir(t, "a")
+	defer os.RemoveAll(tmp)
+	startLoop(tmp)
+
+	sendKeys("Nhello")
+	sendKey(termbox.KeyEnter)
+	waitLoop()
+
+	post(func() {
+		fi, err := wo.CurrentGroup().Current().CurrentFile()
+		if err != nil || fi.Name() != "hello" || mode != ModeNormal {
+			t.Errorf("new file is not selected, mode %d", mode)
+		}
+	})
+	waitLoop()
+	if _, err := os.Stat(filepath.Join(tmp, "hello")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoopAsk(t *testing.T) {
+	tmp := tempDir(t, "a")
+	defer os.RemoveAll(tmp)
+	startLoop(tmp)
+
+	// the asker owns the state until it returns, the changes posted meanwhile wait for it
+	answer := make(chan string, 1)
+	steps := make([]string, 0)
+	asking := make(chan bool)
+	post(func() {
+		steps = append(steps, "ask")
+		close(asking)
+		answer <- model.Ask("password", true)
+		steps = append(steps, "answered")
+	})
+	post(func() { steps = append(steps, "posted") })
+
+	// the keys wait until the asker is running, they are for the answer then
+	<-asking
+	sendKeys("secret")
+	sendKey(termbox.KeyEnter)
+	if v := <-answer; v != "secret" {
+		t.Errorf("expect answer secret, got %s", v)
+	}
+	waitLoop()
+	if s := strings.Join(steps, " "); s != "ask answered posted" {
+		t.Errorf("the posted change runs inside the asker: %s", s)
+	}
+
+	// a request of task does not take the input of others, it waits until the input is finished
+	os.Mkdir(filepath.Join(tmp, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(tmp, "sub", "a"), []byte("new"), 0644)
+	finished := finishedTasks(1)
+	sendKeys("fx")
+	post(func() {
+		item, _ := model.Load(context.Background(), filepath.Join(tmp, "sub", "a"))
+		wo.Clip = []model.FileItem{item}
+		ac.copyFile()
+	})
+	for queued := false; !queued; {
+		ch := make(chan bool)
+		post(func() { ch <- len(requests) == 1 })
+		queued = <-ch
+	}
+	post(func() {
+		if inputer.Name() != "FILTER" || inputer.Get() != "x" {
+			t.Errorf("the input is taken by %s", inputer.Name())
+		}
+	})
+	waitLoop()
+	sendKey(termbox.KeyEnter)
+	sendKeys("y")
+	sendKey(termbox.KeyEnter)
+
+	waitTask(t, finished)
+	if bs, _ := ioutil.ReadFile(filepath.Join(tmp, "a")); string(bs) != "new" {
+		t.Errorf("file is not overridden: %s", bs)
+	}
+}
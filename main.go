@@ -17,17 +17,29 @@ const (
 )
 
 var (
-	home      = os.Getenv("HOME")
-	configDir = filepath.Join(home, ".config/fff")
-	wd, _     = os.Getwd()
-	quit      = make(chan int)
-	cfg       = initConfig()
+	home          = os.Getenv("HOME")
+	args, argsErr = parseArgs(os.Args[1:])
+
+	// legacyDir the dir of both config and data before the XDG base dirs are followed
+	legacyDir  = filepath.Join(home, ".config", "fff")
+	configDir  = findConfigDir()
+	configFile = findConfigFile()
+	dataDir    = xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	cacheDir   = xdgDir("XDG_CACHE_HOME", ".cache")
+
+	wd, _ = os.Getwd()
+	quit  = make(chan int)
+	cfg   = initConfig()
 
 	ac         *action
 	wo         *model.Workspace
 	maxColumns int
 	gui        *ui.UI
 	delay      func() error
+
+	// set by the state loop when the ui is going to stop, keys are ignored until it is started again
+	stopping = false
+	stopCode = 0
 )
 
 func init() {
@@ -35,58 +47,89 @@ func init() {
 	model.SetDefault(cfg.shell, cfg.pager, cfg.editor)
 }
 
-func start(redraw bool) {
+// start the ui and poll termbox events, keys are sent to the state loop
+func start(redraw bool, err error) {
 	if err := termbox.Init(); err != nil {
 		panic(err)
 	}
 
-	if cfg.singleColumnMode {
-		maxColumns = 1
-	} else {
-		w, _ := termbox.Size()
-		maxColumns = w/columnWidth + 1
-	}
-
 	if redraw {
 		gui.Redraw()
 	} else {
 		gui = ui.Start(wo)
+		startWatch()
+		go serve(nil)
 	}
-	kbdStart()
+
+	ready := make(chan bool)
+	post(func() {
+		defer close(ready)
+		updateMaxColumns()
+		stopping = false
+		resetPreview()
+		updateWatch()
+		if err != nil {
+			ui.MessageEvent.Send(err.Error())
+		}
+		if !redraw {
+			offerResume()
+		}
+		reloadIfPending()
+	})
+	<-ready
 
 	for {
 		switch ev := termbox.PollEvent(); ev.Type {
 		case termbox.EventKey:
-			quitIt := 0
-			if isShell(ev) {
-				kbdHandleNormal(ev)
-				quitIt = 2
-			}
-
-			if quitIt == 0 && isQuit(ev) {
-				quitIt = 1
-			}
-
-			if quitIt != 0 {
-				ui.GuiQuit <- true
-				termbox.Close()
-				kbdQuit <- true
-				quit <- quitIt
-				return
-			}
 			kbd <- ev
 		case termbox.EventResize:
-			gui = ui.Recreate(wo)
+			post(recreateUI)
+		case termbox.EventInterrupt:
+			ui.GuiQuit <- true
+			termbox.Close()
+			quit <- stopCode
+			return
 		}
 	}
 }
 
+// updateMaxColumns the columns can be shown in the width of terminal
+func updateMaxColumns() {
+	if cfg.singleColumnMode {
+		maxColumns = 1
+	} else {
+		w, _ := termbox.Size()
+		maxColumns = w/columnWidth + 1
+	}
+}
+
+// recreateUI create the ui again for the new size or colors, the views of the mode are rendered again
+func recreateUI() {
+	gui = ui.Recreate(wo)
+	resetPreview()
+	if mode == ModeHex {
+		hexView.render("")
+	}
+	if mode == ModeHistory {
+		historyView.render(historyHelp)
+	}
+	if mode == ModeSums {
+		sumsView.render(sumsHelp)
+	}
+}
+
+// stopUI stop polling termbox events, main gets the code after the ui is closed
+func stopUI(code int) {
+	stopping, stopCode = true, code
+	go termbox.Interrupt()
+}
+
 func wdFromArgs() {
-	if len(os.Args) < 2 {
+	if args.path == "" {
 		return
 	}
 
-	s := os.Args[1]
+	s := args.path
 	if !filepath.IsAbs(s) {
 		s = filepath.Join(wd, s)
 	}
@@ -117,39 +160,60 @@ func checkWd() {
 }
 
 func main() {
-	if len(os.Args) > 1 {
-		h := os.Args[1]
-		if h == "-h" || h == "--help" {
-			fmt.Println(usageString)
-			return
-		}
+	if argsErr != nil {
+		fmt.Fprintln(os.Stderr, "fff:", argsErr)
+		fmt.Fprintln(os.Stderr, usageString)
+		os.Exit(2)
+	}
+	if args.help {
+		fmt.Println(usageString)
+		return
+	}
+	if args.checkConfig {
+		os.Exit(runCheckConfig())
 	}
 
 	checkWd()
-	wo = model.NewWorkspace(maxGroups, wd, configDir)
+	var migrateErr error
+	dataDir, migrateErr = migrateData(legacyDir, dataDir)
+	model.SetCacheDir(cacheDir)
+	wo = model.NewWorkspace(maxGroups, wd, dataDir, cfg.preview)
+	cfg.applyCopy()
 	ac = newAction()
 
-	go handleUserRequest()
-	go start(false)
+	watchConfig()
+	warning := configWarning()
+	if migrateErr != nil {
+		warning = migrateErr
+	}
+	go start(false, warning)
 	for {
 		switch ev := <-quit; ev {
 		case 1:
+			wo.Views.Flush()
+			model.CleanFetched()
 			return
 		case 2:
-			if delay == nil {
-				go start(true)
-				break
-			}
-			err := delay()
-			delay = nil
-			go start(true)
-			if err != nil {
-				ui.MessageEvent.Send(err.Error())
+			var err error
+			if delay != nil {
+				err = delay()
+				delay = nil
 			}
+			go start(true, err)
 		}
 	}
 }
 
-const usageString = `Usage: fff [PATH]
+const usageString = `Usage: fff [--config FILE] [PATH]
+       fff [--config FILE] --check-config
+
+Options:
+  --config FILE     use FILE instead of config.yml in the config dir
+  --check-config    check the config file, exit 1 on errors
+
+Environment:
+  FFF_CONFIG_HOME   the config dir, default $XDG_CONFIG_HOME/fff or ~/.config/fff
+  XDG_DATA_HOME     bookmarks, views, recent paths and inputs are in its fff dir, default ~/.local/share/fff
+  XDG_CACHE_HOME    temp files of ssh and opened files are in its fff dir, default ~/.cache/fff
 
 Website: https://github.com/jacokoo/fff`
@@ -1,12 +1,14 @@
 package model
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -53,8 +55,14 @@ func (da *defaultArchiveItem) ipath() string    { return da.p }
 func (da *defaultArchiveItem) depth() int       { return da.d }
 
 func archiveTo(from archiveItem, to string) (FileItem, error) {
-	p := path.Clean(to)
-	p = path.Join(from.ipath(), p)
+	p := path.Join(from.ipath(), to)
+	if path.IsAbs(to) {
+		// an absolute path starts from the root of the archive, such as the path loaded from a.zip@zip:///b
+		p = strings.TrimPrefix(path.Clean(to), "/")
+	}
+	if p == "" || p == "." || p == "/" {
+		return from.archive().root(), nil
+	}
 	for _, v := range from.archive().items() {
 		switch tt := v.(type) {
 		case archiveItem:
@@ -156,13 +164,7 @@ type readCloserN struct {
 }
 
 func (rc *readCloserN) Close() error {
-	var ers []string
-	for _, v := range rc.closers {
-		if err := v.Close(); err != nil {
-			ers = append(ers, err.Error())
-		}
-	}
-	return errors.New(strings.Join(ers, "\n"))
+	return closeAll(rc.closers)
 }
 
 func newReadCloser(reader io.Reader, closers ...io.Closer) io.ReadCloser {
@@ -175,12 +177,20 @@ type writeCloserN struct {
 }
 
 func (rc *writeCloserN) Close() error {
+	return closeAll(rc.closers)
+}
+
+// closeAll close all of closers, the errors are joined, nil if none of them fails
+func closeAll(closers []io.Closer) error {
 	var ers []string
-	for _, v := range rc.closers {
+	for _, v := range closers {
 		if err := v.Close(); err != nil {
 			ers = append(ers, err.Error())
 		}
 	}
+	if len(ers) == 0 {
+		return nil
+	}
 	return errors.New(strings.Join(ers, "\n"))
 }
 
@@ -188,6 +198,33 @@ func newWriteCloser(writer io.Writer, closers ...io.Closer) io.WriteCloser {
 	return &writeCloserN{writer, closers}
 }
 
+type closerFunc func() error
+
+func (fn closerFunc) Close() error {
+	return fn()
+}
+
+// closeOnDone close c when ctx is done, so that the blocked reading or writing on it returns
+// the returned closer stops the watching, it can be called more than once
+func closeOnDone(ctx context.Context, c io.Closer) io.Closer {
+	stop, stopped := make(chan bool), make(chan bool)
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-stop:
+		}
+	}()
+
+	once := new(sync.Once)
+	return closerFunc(func() error {
+		once.Do(func() { close(stop) })
+		<-stopped
+		return nil
+	})
+}
+
 type archiveOp struct {
 	archiveItem
 }
@@ -224,12 +261,12 @@ type archiveDirOp struct {
 	*archiveOp
 }
 
-func (*archiveDirOp) IsDir() bool                      { return true }
-func (td *archiveDirOp) To(p string) (FileItem, error) { return archiveTo(td, p) }
-func (td *archiveDirOp) Read() ([]FileItem, error)     { return archiveChildren(td), nil }
-func (td *archiveDirOp) NewFile(string) error          { return td.archive().error("new file is not supported") }
-func (td *archiveDirOp) NewDir(string) error           { return td.archive().error("new dir is not supported") }
-func (td *archiveDirOp) Move([]FileItem) error         { return td.archive().error("move is not supported") }
+func (*archiveDirOp) IsDir() bool                                 { return true }
+func (td *archiveDirOp) To(p string) (FileItem, error)            { return archiveTo(td, p) }
+func (td *archiveDirOp) Read(context.Context) ([]FileItem, error) { return archiveChildren(td), nil }
+func (td *archiveDirOp) NewFile(string) error                     { return td.archive().error("new file is not supported") }
+func (td *archiveDirOp) NewDir(string) error                      { return td.archive().error("new dir is not supported") }
+func (td *archiveDirOp) Move([]FileItem) error                    { return td.archive().error("move is not supported") }
 
 func (td *archiveDirOp) Write([]FileItem) (Task, error) {
 	return nil, td.archive().error("write to dir is not supported")
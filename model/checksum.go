@@ -0,0 +1,251 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SumsFile the name of the file the checksums are written to
+const SumsFile = "SHA256SUMS"
+
+var (
+	verifyLock = new(sync.Mutex)
+	verifyCopy bool
+)
+
+// SetVerifyCopy if the copied files are compared with their sources by checksum
+func SetVerifyCopy(verify bool) {
+	verifyLock.Lock()
+	defer verifyLock.Unlock()
+	verifyCopy = verify
+}
+
+func isVerifyCopy() bool {
+	verifyLock.Lock()
+	defer verifyLock.Unlock()
+	return verifyCopy
+}
+
+// checksummer a file can be hashed without reading it through, such as a remote file
+type checksummer interface {
+	Checksum(ctx context.Context) (string, error)
+}
+
+// Checksum the sha256 of item in hex, the reading stops when ctx is done
+func Checksum(ctx context.Context, item FileItem) (string, error) {
+	if cs, ok := item.(checksummer); ok {
+		return cs.Checksum(ctx)
+	}
+
+	op, ok := item.(FileOp)
+	if !ok || item.IsDir() {
+		return "", fmt.Errorf("%s is not a file", item.Name())
+	}
+	r, err := op.Reader(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	buf := make([]byte, 32*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, err := r.Read(buf)
+		h.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkCopy compare the checksums of the copied file and its source if the copies are verified
+// copied is loaded after the copy is done, nothing is reported if ctx is done
+func checkCopy(ctx context.Context, source FileItem, copied func() (FileItem, error)) error {
+	if !isVerifyCopy() || ctx.Err() != nil {
+		return nil
+	}
+
+	err := func() error {
+		item, err := copied()
+		if err != nil {
+			return err
+		}
+		s1, err := Checksum(ctx, source)
+		if err != nil {
+			return err
+		}
+		s2, err := Checksum(ctx, item)
+		if err != nil {
+			return err
+		}
+		if s1 != s2 {
+			return fmt.Errorf("%s: checksum mismatch, source %s, copy %s", item.Path(), s1[:12], s2[:12])
+		}
+		return nil
+	}()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// Sum the checksum of a file, Name is relative to the dir the files are selected in
+// Hash is empty if the file is not hashed yet
+type Sum struct {
+	Name string
+	Hash string
+	Err  error
+}
+
+// Sums the checksums computed by a task, it is safe for concurrent use
+type Sums struct {
+	sums     []Sum
+	finished bool
+	lock     *sync.Mutex
+}
+
+func (s *Sums) init(root string, files []FileItem) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sums = make([]Sum, len(files))
+	for i, v := range files {
+		name, err := filepath.Rel(root, v.Path())
+		if err != nil {
+			name = v.Path()
+		}
+		s.sums[i].Name = name
+	}
+}
+
+func (s *Sums) set(i int, hash string, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sums[i].Hash, s.sums[i].Err = hash, err
+}
+
+func (s *Sums) finish() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.finished = true
+}
+
+// List the sums, in the order of the files
+func (s *Sums) List() []Sum {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Sum(nil), s.sums...)
+}
+
+// IsFinished if all the files are hashed, the sums of a canceled task are not finished
+func (s *Sums) IsFinished() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.finished
+}
+
+// NewChecksumTask create a task computing the checksums of items, the files in dirs are included
+// the dirs are walked in the task, a failed file is kept in the sums with its error
+func NewChecksumTask(root string, items []FileItem) (Task, *Sums) {
+	sums := &Sums{nil, false, new(sync.Mutex)}
+	task := NewTask("Checksum", func(ctx context.Context, progress chan<- int, eh chan<- error) {
+		defer close(progress)
+		defer close(eh)
+
+		files, err := collectFiles(ctx, items)
+		if err != nil {
+			reportError(ctx, eh, err)
+			return
+		}
+		if len(files) == 0 {
+			eh <- errors.New("no files to checksum")
+			return
+		}
+
+		sums.init(root, files)
+		for i, v := range files {
+			if err := waitResumed(ctx); err != nil {
+				return
+			}
+			hash, err := Checksum(ctx, v)
+			if ctx.Err() != nil {
+				return
+			}
+			sums.set(i, hash, err)
+			if err != nil {
+				eh <- err
+			}
+			progress <- (i + 1) * 100 / len(files)
+		}
+		sums.finish()
+	})
+	return task, sums
+}
+
+// collectFiles the files of items and in the dirs of them, the walk stops when ctx is done
+func collectFiles(ctx context.Context, items []FileItem) ([]FileItem, error) {
+	re := make([]FileItem, 0)
+	for _, v := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !v.IsDir() {
+			re = append(re, v)
+			continue
+		}
+
+		its, err := v.(DirOp).Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fs, err := collectFiles(ctx, its)
+		if err != nil {
+			return nil, err
+		}
+		re = append(re, fs...)
+	}
+	return re, nil
+}
+
+// WriteSums write the sums to SHA256SUMS in dir, in the format of sha256sum, the failed files are left out
+func WriteSums(dir FileItem, sums []Sum) error {
+	lines := make([]string, 0, len(sums))
+	for _, v := range sums {
+		if v.Err == nil && v.Hash != "" {
+			lines = append(lines, fmt.Sprintf("%s  %s\n", v.Hash, filepath.ToSlash(v.Name)))
+		}
+	}
+
+	op := dir.(DirOp)
+	item, err := op.To(SumsFile)
+	if err != nil {
+		if err = op.NewFile(SumsFile); err != nil {
+			return err
+		}
+		if item, err = op.To(SumsFile); err != nil {
+			return err
+		}
+	}
+
+	w, err := item.(FileOp).Writer(os.O_WRONLY | os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	_, err = io.WriteString(w, strings.Join(lines, ""))
+	return err
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// changingItem a source changed after it is copied
+type changingItem struct {
+	streamItem
+}
+
+func (c *changingItem) Path() string { return "/changing" }
+
+func (c *changingItem) Reader(ctx context.Context) (io.ReadCloser, error) {
+	r, err := c.streamItem.Reader(ctx)
+	c.data = []byte("changed")
+	return r, err
+}
+
+func TestChecksumAndVerify(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-sums")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	os.MkdirAll(filepath.Join(tmp, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(tmp, "a"), []byte("hello\n"), 0644)
+	ioutil.WriteFile(filepath.Join(tmp, "sub", "b"), nil, 0644)
+
+	root, _ := Load(context.Background(), tmp)
+	its, _ := root.(DirOp).Read(context.Background())
+	tm := NewTaskManager()
+	finished := make(chan Task, 10)
+	tm.Attach(NewTaskListener(nil, func(task Task) { finished <- task }, nil))
+
+	// the walk stops with the task, the sums are not finished
+	canceled, sums := NewChecksumTask(tmp, its)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs := make(chan error)
+	go drainError(errs)
+	canceled.Start(ctx, errs)
+	if sums.IsFinished() || len(sums.List()) != 0 {
+		t.Errorf("expect no sums of the canceled task, got %v", sums.List())
+	}
+
+	task, sums := NewChecksumTask(tmp, its)
+	for err := range tm.Submit(task) {
+		t.Error(err)
+	}
+	<-finished
+	if !sums.IsFinished() {
+		t.Error("expect the sums finished")
+	}
+
+	if err := WriteSums(root, sums.List()); err != nil {
+		t.Fatal(err)
+	}
+	bs, _ := ioutil.ReadFile(filepath.Join(tmp, SumsFile))
+	expect := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  sub/b\n" +
+		"5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  a\n"
+	if string(bs) != expect {
+		t.Errorf("unexpected %s:\n%s", SumsFile, bs)
+	}
+
+	SetVerifyCopy(true)
+	defer SetVerifyCopy(false)
+	dst := filepath.Join(tmp, "dst")
+	os.Mkdir(dst, 0755)
+	to, _ := Load(context.Background(), dst)
+	from := &changingItem{streamItem{data: []byte("original")}}
+	task, err = to.(DirOp).Write([]FileItem{from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := make([]string, 0)
+	for v := range tm.Submit(task) {
+		msgs = append(msgs, v)
+	}
+	<-finished
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "checksum mismatch") {
+		t.Errorf("expect a checksum mismatch, got %v", msgs)
+	}
+}
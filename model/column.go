@@ -1,11 +1,34 @@
 package model
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	readBatchSize    = 1000
+	progressInterval = 300 * time.Millisecond
+)
+
+var (
+	// ErrLoadTimeout reading dir takes too long
+	ErrLoadTimeout = errors.New("read dir timeout")
+
+	// ErrLoadCanceled reading dir is canceled
+	ErrLoadCanceled = errors.New("read dir canceled")
+)
+
 // Column represent a directory
 type Column interface {
 	File() FileItem
 	Path() string
 	Update()
 	Refresh(FileItem) error
+	IsLoading() bool
+	IsPartial() bool
+	Loaded(items []FileItem, partial bool)
+	Reload([]FileItem)
 	MarkedOrSelected() []FileItem
 	ToggleMarkAll()
 
@@ -56,9 +79,20 @@ func (bc *BaseColumn) ToggleMarkAll() {
 	bc.ClearMark()
 }
 
+func newBaseColumn(items []FileItem, vs *ViewSetting) *BaseColumn {
+	fl := &BaseFileList{vs.Order, items, items, vs.ShowDetail}
+	se := &BaseSelector{0, fl}
+	ma := &BaseMarker{nil, se}
+	fi := &BaseFilter{vs.Filter, vs.ShowHidden, fl}
+	return &BaseColumn{fl, se, ma, fi}
+}
+
 // LocalColumn use local file system
 type LocalColumn struct {
-	item FileItem
+	item    FileItem
+	views   *ViewSettings
+	loading bool
+	partial bool
 	*BaseColumn
 }
 
@@ -72,41 +106,152 @@ func (bc *LocalColumn) Path() string {
 	return bc.item.Path()
 }
 
-// Refresh the specified path
+// Refresh the specified path, the view setting of path is applied if it changed
 func (bc *LocalColumn) Refresh(item FileItem) error {
+	vs := &ViewSetting{bc.Order(), bc.IsShowHidden(), bc.IsShowDetail(), bc.Filter()}
 	if item != nil {
+		if item.Path() != bc.item.Path() {
+			vs = bc.views.Get(item.Path())
+		}
 		bc.item = item
 	}
 
-	items, err := bc.item.(DirOp).Read()
+	items, err := bc.item.(DirOp).Read(context.Background())
 	if err != nil {
 		return err
 	}
 
-	fl := &BaseFileList{bc.Order(), items, items, false}
-	se := &BaseSelector{0, fl}
-	ma := &BaseMarker{nil, se}
-	fi := &BaseFilter{bc.Filter(), bc.IsShowHidden(), fl}
-
-	bc.FileList = fl
-	bc.Selector = se
-	bc.Marker = ma
-	bc.Filterer = fi
+	bc.loading, bc.partial = false, false
+	bc.BaseColumn = newBaseColumn(items, vs)
 	bc.Update()
 	return nil
 }
 
-// NewLocalColumn create column
-func NewLocalColumn(item FileItem) (Column, error) {
-	items, err := item.(DirOp).Read()
+// IsLoading if the items are being read in background
+func (bc *LocalColumn) IsLoading() bool {
+	return bc.loading
+}
+
+// IsPartial if only part of the items are read
+func (bc *LocalColumn) IsPartial() bool {
+	return bc.partial
+}
+
+// Loaded set the items read in background, partial means there are more items to read
+func (bc *LocalColumn) Loaded(items []FileItem, partial bool) {
+	bc.Reload(items)
+	bc.loading, bc.partial = false, partial
+}
+
+// Reload set items read again, the selected and marked items are kept by name
+func (bc *LocalColumn) Reload(items []FileItem) {
+	idx, current := bc.Current(), ""
+	if fi, err := bc.CurrentFile(); err == nil {
+		current = fi.Name()
+	}
+	marked := make(map[string]bool)
+	for _, v := range bc.Marked() {
+		marked[v.Name()] = true
+	}
+
+	vs := &ViewSetting{bc.Order(), bc.IsShowHidden(), bc.IsShowDetail(), bc.Filter()}
+	bc.BaseColumn = newBaseColumn(items, vs)
+	bc.Update()
+
+	if !bc.SelectByName(current) {
+		if idx >= len(bc.Files()) {
+			idx = len(bc.Files()) - 1
+		}
+		bc.Select(idx)
+	}
+	if len(marked) == 0 {
+		return
+	}
+	for i, v := range bc.Files() {
+		if marked[v.Name()] {
+			bc.Mark(i)
+		}
+	}
+}
+
+// NewLocalColumn create column, it is displayed by the setting of its path in views
+func NewLocalColumn(item FileItem, views *ViewSettings) (Column, error) {
+	items, err := item.(DirOp).Read(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	fl := &BaseFileList{OrderByName, items, items, false}
-	se := &BaseSelector{0, fl}
-	ma := &BaseMarker{nil, se}
-	fi := &BaseFilter{"", false, fl}
-	fi.DoFilter()
-	fl.Sort(OrderByName)
-	return &LocalColumn{item, &BaseColumn{fl, se, ma, fi}}, nil
+	bc := newBaseColumn(items, views.Get(item.Path()))
+	bc.DoFilter()
+	bc.Sort(bc.Order())
+	return &LocalColumn{item, views, false, false, bc}, nil
+}
+
+// NewLoadingColumn create an empty column, the items should be set by Loaded
+func NewLoadingColumn(item FileItem, views *ViewSettings) Column {
+	return &LocalColumn{item, views, true, false, newBaseColumn(nil, views.Get(item.Path()))}
+}
+
+type batchReader interface {
+	readBatch(ctx context.Context, size int, fn func([]FileItem) bool) ([]FileItem, error)
+}
+
+// ReadDir read items of item, it returns early when ctx is done or nothing is read in timeout
+// the reading in background is stopped when it returns
+// if the dir can be read in batches, progress is called with the items read so far,
+// the first batch immediately and then every progressInterval
+func ReadDir(ctx context.Context, item FileItem, timeout time.Duration, progress func([]FileItem)) ([]FileItem, error) {
+	type result struct {
+		items []FileItem
+		err   error
+	}
+
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan *result, 1)
+	batches := make(chan []FileItem, 16)
+	go func() {
+		var items []FileItem
+		var err error
+		if br, ok := item.(batchReader); ok {
+			items, err = br.readBatch(rctx, readBatchSize, func(batch []FileItem) bool {
+				select {
+				case batches <- batch:
+					return true
+				case <-rctx.Done():
+					return false
+				}
+			})
+		} else {
+			items, err = item.(DirOp).Read(rctx)
+		}
+		ch <- &result{items, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var read []FileItem
+	var last time.Time
+	for {
+		select {
+		case batch := <-batches:
+			read = append(read, batch...)
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(timeout)
+
+			if progress != nil && time.Since(last) >= progressInterval {
+				last = time.Now()
+				progress(append([]FileItem(nil), read...))
+			}
+		case re := <-ch:
+			return re.items, re.err
+		case <-ctx.Done():
+			return nil, ErrLoadCanceled
+		case <-timer.C:
+			return nil, ErrLoadTimeout
+		}
+	}
 }
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandTask a task running a shell command, the output of the command is kept
+type CommandTask struct {
+	output    *bytes.Buffer
+	succeeded bool
+	lock      *sync.Mutex
+	*DefaultTask
+}
+
+// NewCommandTask create a task running script by the shell in dir, the command is killed when the task is canceled
+func NewCommandTask(name, dir, script string) *CommandTask {
+	ct := &CommandTask{new(bytes.Buffer), false, new(sync.Mutex), nil}
+	ct.DefaultTask = &DefaultTask{name, func(ctx context.Context, progress chan<- int, eh chan<- error) {
+		defer close(progress)
+		defer close(eh)
+
+		cm := exec.CommandContext(ctx, shell, "-c", script)
+		cm.Dir = dir
+		cm.Stdout = ct
+		cm.Stderr = ct
+		// the children of the shell may keep the output open after the shell is killed
+		cm.WaitDelay = time.Second
+		err := cm.Run()
+		switch {
+		case ctx.Err() != nil:
+		case err != nil:
+			if line := ct.LastLine(); line != "" {
+				err = fmt.Errorf("%s: %s", err.Error(), line)
+			}
+			eh <- err
+		default:
+			ct.lock.Lock()
+			ct.succeeded = true
+			ct.lock.Unlock()
+			progress <- 100
+		}
+	}, nil, newPauser(), newLimiter(0), func() Task { return NewCommandTask(name, dir, script) }, newProgresser(100)}
+	return ct
+}
+
+// Write append the output of the command
+func (ct *CommandTask) Write(bs []byte) (int, error) {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	return ct.output.Write(bs)
+}
+
+// Output the stdout and stderr of the command so far
+func (ct *CommandTask) Output() string {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	return ct.output.String()
+}
+
+// LastLine the last line of the output which is not empty
+func (ct *CommandTask) LastLine() string {
+	lines := strings.Split(strings.TrimRight(ct.Output(), "\r\n\t "), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// Succeeded if the command exited with 0
+func (ct *CommandTask) Succeeded() bool {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	return ct.succeeded
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandTask(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-command")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	SetDefault("sh", pager, editor)
+
+	tm := NewTaskManager()
+	finished := make(chan Task, 10)
+	tm.Attach(NewTaskListener(nil, func(task Task) { finished <- task }, nil))
+	run := func(task Task) *TaskRecord {
+		for range tm.Submit(task) {
+		}
+		<-finished
+		return tm.History()[0]
+	}
+
+	ct := NewCommandTask("pwd", tmp, "pwd; echo done")
+	if r := run(ct); r.Status() != TaskCompleted || !ct.Succeeded() {
+		t.Fatalf("expect completed, got %v %v", r.Status(), r.Errors())
+	}
+	if lines := strings.Split(ct.Output(), "\n"); !strings.HasSuffix(lines[0], filepath.Base(tmp)) || ct.LastLine() != "done" {
+		t.Errorf("unexpected output: %q", ct.Output())
+	}
+
+	ct = NewCommandTask("fail", tmp, "echo oops >&2; exit 3")
+	r := run(ct)
+	if es := r.Errors(); r.Status() != TaskFailed || ct.Succeeded() || len(es) != 1 || !strings.Contains(es[0].Err.Error(), "oops") {
+		t.Errorf("expect failed with the output, got %v %v", r.Status(), es)
+	}
+	if retry := r.Retry(); retry == nil || retry.Name() != "fail" {
+		t.Error("expect the command can be retried")
+	}
+
+	ct = NewCommandTask("sleep", tmp, "sleep 10; echo late")
+	tm.Submit(ct)
+	time.Sleep(50 * time.Millisecond)
+	tm.Cancel(ct)
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command is not killed by cancel")
+	}
+	if r := tm.History()[0]; r.Status() != TaskCanceled {
+		t.Errorf("expect canceled, got %v", r.Status())
+	}
+}
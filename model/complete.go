@@ -0,0 +1,77 @@
+package model
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var loaderPrefix = regexp.MustCompile(`@(\w*)$`)
+
+// Complete the candidates of word, the path being typed, each candidate is word with its last part completed
+// a relative word is resolved from dir, a word ending with @ and part of a loader name is completed to a loader
+// prefix such as @ssh://, the dirs end with their separator so that the completion can go on into them
+// a word with an unknown loader has no candidates
+func Complete(ctx context.Context, dir FileItem, word string) ([]string, error) {
+	if m := loaderPrefix.FindStringSubmatchIndex(word); m != nil {
+		return completeLoader(word[:m[0]], word[m[2]:m[3]]), nil
+	}
+
+	pis, err := ParsePath(word)
+	if err != nil {
+		return nil, nil
+	}
+	last := pis[len(pis)-1]
+	base := last.Path[strings.LastIndex(last.Path, last.Seperator)+1:]
+	parent := word[:len(word)-len(base)]
+
+	var from FileItem
+	switch {
+	case filepath.IsAbs(word):
+		from, err = Load(ctx, parent)
+	case parent == "":
+		from = dir
+	default:
+		from, err = dir.(DirOp).To(parent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	op, ok := from.(DirOp)
+	if !ok || !from.IsDir() {
+		return nil, nil
+	}
+
+	items, err := op.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sep, _ := ParsePath(from.Path())
+	re := make([]string, 0)
+	for _, v := range items {
+		name := v.Name()
+		if !strings.HasPrefix(name, base) || (base == "" && strings.HasPrefix(name, ".")) {
+			continue
+		}
+		if v.IsDir() {
+			name += sep[len(sep)-1].Seperator
+		}
+		re = append(re, parent+name)
+	}
+	sort.Strings(re)
+	return re, nil
+}
+
+// completeLoader complete the loader name started with prefix, the local loader is implied so it is left out
+func completeLoader(path, prefix string) []string {
+	re := make([]string, 0)
+	for name, v := range loaderMap {
+		if name != "file" && strings.HasPrefix(name, prefix) {
+			re = append(re, path+LoaderString(v))
+		}
+	}
+	sort.Strings(re)
+	return re
+}
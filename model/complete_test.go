@@ -0,0 +1,69 @@
+package model
+
+import (
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-complete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	os.MkdirAll(filepath.Join(tmp, "docs", "notes"), 0755)
+	for _, v := range []string{"docs/a.txt", "docs/b.txt", "docs/.hidden", "dump"} {
+		ioutil.WriteFile(filepath.Join(tmp, v), nil, 0644)
+	}
+
+	dir, _ := Load(context.Background(), tmp)
+	sep := string(filepath.Separator)
+	for word, expect := range map[string]string{
+		"d":         "docs" + sep + ",dump",
+		"docs/":     "docs/a.txt,docs/b.txt,docs/notes" + sep,
+		"docs/.":    "docs/.hidden",
+		"docs/n":    "docs/notes" + sep,
+		tmp + "/du": tmp + "/dump",
+		"x":         "",
+		"/a.zip@s":  "/a.zip@ssh://",
+		"/a.tar@t":  "/a.tar@tar://,/a.tar@tgz://",
+		"a@foo://":  "",
+		"a@foo://b": "",
+	} {
+		cs, err := Complete(context.Background(), dir, word)
+		if err != nil || strings.Join(cs, ",") != expect {
+			t.Errorf("complete %s: expect %s, got %v, %v", word, expect, cs, err)
+		}
+	}
+
+	// the dirs of a loader are completed the same way, such as the dirs of a remote host
+	zp := filepath.Join(tmp, "a.zip")
+	f, _ := os.Create(zp)
+	zw := zip.NewWriter(f)
+	for _, v := range []string{"sub/one", "sub/two", "top"} {
+		zw.Create(v)
+	}
+	zw.Close()
+	f.Close()
+
+	root := zp + "@zip://"
+	zdir, err := Load(context.Background(), root+"/sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for word, expect := range map[string]string{
+		"t":            "two",
+		root + "/s":    root + "/sub/",
+		root + "/sub/": root + "/sub/one," + root + "/sub/two",
+	} {
+		cs, err := Complete(context.Background(), zdir, word)
+		if err != nil || strings.Join(cs, ",") != expect {
+			t.Errorf("complete %s: expect %s, got %v, %v", word, expect, cs, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Text encodings can be detected
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingGBK     = "gbk"
+	EncodingLatin1  = "latin-1"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+
+	encodings = map[string]encoding.Encoding{
+		EncodingUTF16LE: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+		EncodingUTF16BE: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+		EncodingGBK:     simplifiedchinese.GBK,
+		EncodingLatin1:  charmap.ISO8859_1,
+	}
+)
+
+// trimPartialChar drop the last char if it is cut off by the read limit
+// a utf-8 char is checked first, since a utf-8 text cut in a char may still look like gbk
+func trimPartialChar(bs []byte) []byte {
+	if utf8.Valid(bs) {
+		return bs
+	}
+	for i := 1; i < utf8.UTFMax && i <= len(bs); i++ {
+		tail := bs[len(bs)-i:]
+		if !utf8.RuneStart(tail[0]) {
+			continue
+		}
+		if !utf8.FullRune(tail) && utf8.Valid(bs[:len(bs)-i]) {
+			return bs[:len(bs)-i]
+		}
+		break
+	}
+
+	if validGBK(bs) {
+		return bs
+	}
+	if len(bs) > 0 && validGBK(bs[:len(bs)-1]) {
+		return bs[:len(bs)-1]
+	}
+	return bs
+}
+
+// validGBK every byte above 0x7f must be a part of a double byte char
+func validGBK(bs []byte) bool {
+	for i := 0; i < len(bs); i++ {
+		b := bs[i]
+		if b < 0x80 {
+			continue
+		}
+		if b == 0x80 || b == 0xff {
+			return false
+		}
+		if i+1 == len(bs) {
+			return false
+		}
+		t := bs[i+1]
+		if t < 0x40 || t == 0x7f || t == 0xff {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func isControl(b byte) bool {
+	switch b {
+	case '\t', '\n', '\r', '\f', '\v', 0x1b:
+		return false
+	}
+	return b < 0x20 || b == 0x7f
+}
+
+// looksBinary a text file has no NUL and only a few control chars
+func looksBinary(bs []byte) bool {
+	if bytes.IndexByte(bs, 0) != -1 {
+		return true
+	}
+
+	count := 0
+	for _, v := range bs {
+		if isControl(v) {
+			count++
+		}
+	}
+	return count*100 > len(bs)*5
+}
+
+// DetectEncoding detect the encoding of bs, empty string for binary data
+func DetectEncoding(bs []byte) string {
+	switch {
+	case bytes.HasPrefix(bs, bomUTF8):
+		return EncodingUTF8
+	case bytes.HasPrefix(bs, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(bs, bomUTF16BE):
+		return EncodingUTF16BE
+	}
+
+	if looksBinary(bs) {
+		return ""
+	}
+
+	if utf8.Valid(bs) {
+		return EncodingUTF8
+	}
+
+	if validGBK(bs) {
+		return EncodingGBK
+	}
+	return EncodingLatin1
+}
+
+// Decode bs from enc to utf-8, the BOM is dropped
+func Decode(bs []byte, enc string) string {
+	switch enc {
+	case EncodingUTF8:
+		return string(bytes.TrimPrefix(bs, bomUTF8))
+	case EncodingUTF16LE:
+		bs = bytes.TrimPrefix(bs, bomUTF16LE)
+	case EncodingUTF16BE:
+		bs = bytes.TrimPrefix(bs, bomUTF16BE)
+	}
+
+	e, ok := encodings[enc]
+	if !ok {
+		return string(bs)
+	}
+
+	// a double byte char may be cut off by the read limit
+	if enc == EncodingUTF16LE || enc == EncodingUTF16BE {
+		bs = bs[:len(bs)/2*2]
+	}
+	re, err := e.NewDecoder().Bytes(bs)
+	if err != nil {
+		return string(bs)
+	}
+	return string(re)
+}
@@ -0,0 +1,54 @@
+package model
+
+import "testing"
+
+func TestDetectEncoding(t *testing.T) {
+	cases := []struct {
+		data     []byte
+		encoding string
+		text     string
+	}{
+		{[]byte("hello 世界"), EncodingUTF8, "hello 世界"},
+		{[]byte{0xef, 0xbb, 0xbf, 'h', 'i'}, EncodingUTF8, "hi"},
+		{[]byte{0xff, 0xfe, 'h', 0, 'i', 0}, EncodingUTF16LE, "hi"},
+		{[]byte{0xfe, 0xff, 0, 'h', 0, 'i'}, EncodingUTF16BE, "hi"},
+		{[]byte{0xc4, 0xe3, 0xba, 0xc3, 'a'}, EncodingGBK, "你好a"},
+		{[]byte{'c', 'a', 'f', 0xe9}, EncodingLatin1, "café"},
+		{[]byte{0x7f, 'E', 'L', 'F', 0, 1}, "", ""},
+	}
+
+	for _, c := range cases {
+		enc := DetectEncoding(c.data)
+		if enc != c.encoding {
+			t.Errorf("%q: expect encoding %q, got %q", c.data, c.encoding, enc)
+			continue
+		}
+		if enc != "" && Decode(c.data, enc) != c.text {
+			t.Errorf("%q: expect text %q, got %q", c.data, c.text, Decode(c.data, enc))
+		}
+	}
+}
+
+func TestTrimPartialChar(t *testing.T) {
+	cases := []struct {
+		data     []byte
+		text     []byte
+		encoding string
+	}{
+		// 你好世 cut in 世, it is valid gbk before trimmed
+		{[]byte{0xe4, 0xbd, 0xa0, 0xe5, 0xa5, 0xbd, 0xe4, 0xb8}, []byte("你好"), EncodingUTF8},
+		{[]byte("ab世"), []byte("ab世"), EncodingUTF8},
+		{[]byte{'a', 0xc4, 0xe3, 0xba}, []byte{'a', 0xc4, 0xe3}, EncodingGBK},
+		{[]byte{'a', 0xc4, 0xe3}, []byte{'a', 0xc4, 0xe3}, EncodingGBK},
+	}
+
+	for _, c := range cases {
+		bs := trimPartialChar(c.data)
+		if string(bs) != string(c.text) {
+			t.Errorf("%q: expect %q, got %q", c.data, c.text, bs)
+		}
+		if enc := DetectEncoding(bs); enc != c.encoding {
+			t.Errorf("%q: expect encoding %q, got %q", c.data, c.encoding, enc)
+		}
+	}
+}
@@ -11,15 +11,41 @@ var _ = FileItem(new(fileItem))
 var (
 	// RequestCh request user input
 	RequestCh = make(chan *Request)
-
-	// ResponseCh user response for request
-	ResponseCh = make(chan string)
 )
 
-// Request content
+// Request content, the asker is blocked until it is answered
 type Request struct {
 	Title      string
 	IsPassword bool
+
+	// asked by a task, not by the owner of the workspace
+	background bool
+	answer     chan string
+}
+
+// IsBackground if the request is asked by a background task
+func (r *Request) IsBackground() bool {
+	return r.background
+}
+
+// Answer the request
+func (r *Request) Answer(str string) {
+	r.answer <- str
+}
+
+// Ask user input and wait for the answer
+func Ask(title string, isPassword bool) string {
+	return ask(&Request{title, isPassword, false, make(chan string, 1)})
+}
+
+// askInTask ask user input in a background task
+func askInTask(title string) string {
+	return ask(&Request{title, false, true, make(chan string, 1)})
+}
+
+func ask(req *Request) string {
+	RequestCh <- req
+	return <-req.answer
 }
 
 // FileItem represent a file, with absolute path
@@ -41,6 +67,8 @@ type Link interface {
 	IsDir() bool
 }
 
+// fileLink a symbolic link, a local link is resolved when it is read, in the reading goroutine,
+// since sorting needs the type of every target anyway
 type fileLink struct {
 	broken bool
 	target string
@@ -99,17 +127,7 @@ func newFile(path string, info os.FileInfo) *fileItem {
 		st, err := os.Stat(p)
 		link.broken = err != nil
 		link.isDir = err == nil && st.IsDir()
-		tr, _ := os.Readlink(p)
-		link.target = tr
+		link.target, _ = os.Readlink(p)
 	}
 	return &fileItem{p, link, info}
 }
-
-// NewFiles create a file list
-func newFiles(path string, infos []os.FileInfo) []*fileItem {
-	fis := make([]*fileItem, len(infos))
-	for i, v := range infos {
-		fis[i] = newFile(path, v)
-	}
-	return fis
-}
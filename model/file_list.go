@@ -10,8 +10,21 @@ const (
 	OrderByName Order = iota
 	OrderByMTime
 	OrderBySize
+
+	// OrderReverse a flag reversing an order, such as OrderByMTime | OrderReverse for the oldest first
+	OrderReverse Order = 1 << 7
 )
 
+// Base the order without OrderReverse
+func (o Order) Base() Order {
+	return o &^ OrderReverse
+}
+
+// IsReverse if the order is reversed
+func (o Order) IsReverse() bool {
+	return o&OrderReverse != 0
+}
+
 // FileList sortable file list
 type FileList interface {
 	Files() []FileItem
@@ -31,6 +44,21 @@ type BaseFileList struct {
 
 type items []FileItem
 
+type sorter interface {
+	sort.Interface
+	compare(i, j int) int
+}
+
+// reversed reverse a sorter, the dirs are still before the files
+type reversed struct{ sorter }
+
+func (r reversed) Less(i, j int) bool {
+	if c := r.compare(i, j); c != 0 {
+		return c < 0
+	}
+	return r.sorter.Less(j, i)
+}
+
 func (c items) Len() int      { return len(c) }
 func (c items) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
 func (c items) compare(i, j int) int {
@@ -93,14 +121,21 @@ func (c bySize) Less(i, j int) bool {
 
 // Sort files
 func (fl *BaseFileList) Sort(order Order) {
-	switch order {
+	var s sorter
+	switch order.Base() {
 	case OrderByName:
-		sort.Sort(byName{fl.files})
+		s = byName{fl.files}
 	case OrderByMTime:
-		sort.Sort(byMTime{fl.files})
+		s = byMTime{fl.files}
 	case OrderBySize:
-		sort.Sort(bySize{fl.files})
+		s = bySize{fl.files}
+	default:
+		return
+	}
+	if order.IsReverse() {
+		s = reversed{s}
 	}
+	sort.Sort(s)
 	fl.order = order
 }
 
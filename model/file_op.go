@@ -1,6 +1,8 @@
 package model
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -8,15 +10,17 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 var (
 	_ = FileOp(new(file))
 	_ = DirOp(new(dir))
 
-	shell  string
-	pager  string
-	editor string
+	shell    string
+	pager    string
+	editor   string
+	cacheDir string
 )
 
 // SetDefault set default
@@ -26,6 +30,19 @@ func SetDefault(sh, pg, ed string) {
 	editor = ed
 }
 
+// SetCacheDir set the dir of the temp files, such as the files read from ssh servers
+func SetCacheDir(dir string) {
+	cacheDir = dir
+}
+
+// tempDir create a temp dir in the cache dir, or in the temp dir of system if the cache dir can not be created
+func tempDir(prefix string) (string, error) {
+	if cacheDir != "" && os.MkdirAll(cacheDir, 0700) == nil {
+		return ioutil.TempDir(cacheDir, prefix)
+	}
+	return ioutil.TempDir("", prefix)
+}
+
 func newCmd(args string) *exec.Cmd {
 	cm := exec.Command(shell, "-c", args)
 	cm.Stdin = os.Stdin
@@ -44,7 +61,7 @@ type Op interface {
 
 // FileOp file operators
 type FileOp interface {
-	Reader() (io.ReadCloser, error)
+	Reader(context.Context) (io.ReadCloser, error)
 	Writer(int) (io.WriteCloser, error)
 	View() error
 	Edit() error
@@ -53,7 +70,7 @@ type FileOp interface {
 
 // DirOp dir operators
 type DirOp interface {
-	Read() ([]FileItem, error)
+	Read(context.Context) ([]FileItem, error)
 	Write([]FileItem) (Task, error)
 	Move([]FileItem) error
 	NewFile(string) error
@@ -68,7 +85,7 @@ type defaultOp struct {
 }
 
 func (do *defaultOp) Dir() (FileItem, error) {
-	return Load(filepath.Dir(do.Path()))
+	return Load(context.Background(), filepath.Dir(do.Path()))
 }
 
 func (do *defaultOp) Rename(name string) error {
@@ -102,7 +119,7 @@ type defaultFileOp struct {
 	*defaultOp
 }
 
-func (df *defaultFileOp) Reader() (io.ReadCloser, error) {
+func (df *defaultFileOp) Reader(context.Context) (io.ReadCloser, error) {
 	return os.Open(df.Path())
 }
 
@@ -132,6 +149,20 @@ type dir struct {
 	*defaultDirOp
 }
 
+// link a symbolic link, it is a dir or a file depends on the target
+// so that the target is not stated when the link is read
+// it has the ops of both, so the callers must tell a dir by IsDir instead of the ops
+type link struct {
+	*fileItem
+	*defaultFileOp
+	*defaultDirOp
+}
+
+func (ln *link) Dir() (FileItem, error)   { return ln.defaultFileOp.Dir() }
+func (ln *link) Rename(name string) error { return ln.defaultFileOp.Rename(name) }
+func (ln *link) Delete() error            { return ln.defaultFileOp.Delete() }
+func (ln *link) Open() error              { return ln.defaultFileOp.Open() }
+
 func (dd *dir) To(sub string) (FileItem, error) {
 	if filepath.Dir(sub) == sub {
 		return dd, nil
@@ -152,23 +183,56 @@ func (dd *defaultDirOp) To(sub string) (FileItem, error) {
 	return &file{v, &defaultFileOp{&defaultOp{v}}}, nil
 }
 
-func (dd *defaultDirOp) Read() ([]FileItem, error) {
-	fis, err := ioutil.ReadDir(dd.Path())
+var errReadStopped = errors.New("read stopped")
+
+func toItem(v *fileItem) FileItem {
+	op := &defaultOp{v}
+	switch {
+	case v.link != nil:
+		return &link{v, &defaultFileOp{op}, &defaultDirOp{op}}
+	case v.IsDir():
+		return &dir{v, &defaultDirOp{op}}
+	default:
+		return &file{v, &defaultFileOp{op}}
+	}
+}
+
+// readBatch read the dir in batches of size, fn is called with each batch
+// it stops reading if fn returns false or ctx is done
+func (dd *defaultDirOp) readBatch(ctx context.Context, size int, fn func([]FileItem) bool) ([]FileItem, error) {
+	f, err := os.Open(dd.Path())
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	items := newFiles(dd.Path(), fis)
-	rs := make([]FileItem, len(items))
+	rs := make([]FileItem, 0)
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		fis, err := f.Readdir(size)
+		batch := make([]FileItem, len(fis))
+		for i, v := range fis {
+			batch[i] = toItem(newFile(dd.Path(), v))
+		}
+		rs = append(rs, batch...)
+		if len(batch) > 0 && fn != nil && !fn(batch) {
+			return nil, errReadStopped
+		}
 
-	for i, v := range items {
-		if v.IsDir() {
-			rs[i] = &dir{v, &defaultDirOp{&defaultOp{v}}}
-		} else {
-			rs[i] = &file{v, &defaultFileOp{&defaultOp{v}}}
+		if err == io.EOF {
+			return rs, nil
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
-	return rs, nil
+}
+
+func (dd *defaultDirOp) Read(ctx context.Context) ([]FileItem, error) {
+	return dd.readBatch(ctx, readBatchSize, nil)
 }
 
 func (dd *defaultDirOp) NewFile(name string) error {
@@ -191,98 +255,114 @@ func (dd *defaultDirOp) Move(items []FileItem) error {
 	return nil
 }
 
-func (dd *defaultDirOp) write(root string, item FileItem) ([]Task, error) {
+func (dd *defaultDirOp) write(root string, item FileItem, j *Journal) ([]Task, error) {
 	if item.IsDir() {
-		return dd.writeDir(root, item)
+		return dd.writeDir(root, item, j)
 	}
 
-	return []Task{NewTask(item.Name(), func(progress chan<- int, quit <-chan bool, eh chan<- error) {
+	rel, err := filepath.Rel(root, item.Path())
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dd.Path(), rel)
+
+	var e *journalEntry
+	task := NewTransferTask(item.Name(), item.Size(), func(ctx context.Context, meter *Meter, progress chan<- int, eh chan<- error) {
 		defer close(progress)
 		defer close(eh)
 
-		r, err := item.(FileOp).Reader()
+		ctx = throughLoaders(ctx, item.Path(), path)
+		r, err := item.(FileOp).Reader(ctx)
 		if err != nil {
 			eh <- err
 			return
 		}
 		defer r.Close()
 
-		rel, err := filepath.Rel(root, item.Path())
-		if err != nil {
-			eh <- err
-			return
-		}
-		path := filepath.Join(dd.Path(), rel)
 		_, err = os.Stat(path)
 		if err == nil {
-			RequestCh <- &Request{fmt.Sprintf("%s is already exists, override it? (y/n)", path), false}
-			answer := <-ResponseCh
+			answer := askInTask(fmt.Sprintf("%s is already exists, override it? (y/n)", path))
 			if answer != "y" {
+				j.finish(e)
 				return
 			}
 		}
+		j.own(e)
 		err = os.MkdirAll(filepath.Dir(path), 0755)
 		if err != nil {
 			eh <- err
 			return
 		}
 
-		w, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+		w, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 		if err != nil {
 			eh <- err
 			return
 		}
 		defer w.Close()
 
-		buf := make([]byte, 4096)
-		pg := 0
-		si := float64(item.Size())
-
-		var count int64
-		var quited = false
-
-		go func() {
-			<-quit
-			quited = true
-		}()
-
-		for !quited {
-			n, err := r.Read(buf)
-			if n > 0 {
-				_, err2 := w.Write(buf[:n])
-				if err2 != nil {
-					eh <- err2
-					return
-				}
-
-				count += int64(n)
-				pp := int(float64(count) / si * 100)
-				if pp > pg {
-					pg = pp
-					progress <- pg
-				}
+		err = copyData(ctx, w, r, meter, progress)
+		if err == nil {
+			err = checkCopy(ctx, item, func() (FileItem, error) { return Load(ctx, path) })
+		}
+		if err != nil {
+			reportError(ctx, eh, err)
+			return
+		}
+		if ctx.Err() == nil {
+			j.finish(e)
+		}
+	})
+	e = j.add(item.Path(), path, item.Size(), task.Meter())
+	return []Task{task}, nil
+}
+
+// copyData copy r to w until EOF or ctx is done, it holds between chunks while the task is paused,
+// and waits before writing a chunk over the rate limits
+// the copied bytes are added to meter
+// the percentage is sent to progress when it changes, or once in rateInterval to refresh the rate
+// it returns the error of ctx if ctx is done before EOF, so that the copy is not taken as finished
+func copyData(ctx context.Context, w io.Writer, r io.Reader, meter *Meter, progress chan<- int) error {
+	buf := make([]byte, 32*1024)
+	pg, last := -1, time.Now()
+	for {
+		if err := waitResumed(ctx); err != nil {
+			return err
+		}
+
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := throttle(ctx, n); err != nil {
+				return err
 			}
-			if err == io.EOF {
-				break
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
 			}
 
-			if err != nil {
-				eh <- err
-				return
+			meter.Add(int64(n))
+			if pp := meter.Percent(); pp != pg || time.Since(last) >= rateInterval {
+				pg, last = pp, time.Now()
+				progress <- pp
 			}
 		}
-	})}, nil
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
 }
 
-func (dd *defaultDirOp) writeDir(root string, item FileItem) ([]Task, error) {
-	its, err := item.(DirOp).Read()
+func (dd *defaultDirOp) writeDir(root string, item FileItem, j *Journal) ([]Task, error) {
+	its, err := item.(DirOp).Read(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
 	re := make([]Task, 0)
 	for _, v := range its {
-		ts, err := dd.write(root, v)
+		ts, err := dd.write(root, v, j)
 		if err != nil {
 			return re, err
 		}
@@ -291,16 +371,21 @@ func (dd *defaultDirOp) writeDir(root string, item FileItem) ([]Task, error) {
 	return re, nil
 }
 
+// Write copy items into the dir, the pending files are kept in a journal until the copy ends,
+// see Journal
 func (dd *defaultDirOp) Write(items []FileItem) (Task, error) {
 	re := make([]Task, 0)
+	j := newJournal()
 	for _, v := range items {
-		ts, err := dd.write(filepath.Dir(v.Path()), v)
+		ts, err := dd.write(filepath.Dir(v.Path()), v, j)
 		if err != nil {
 			return nil, err
 		}
 		re = append(re, ts...)
 	}
-	return NewBatchTask("Copy", re), nil
+	task := NewBatchTask("Copy", re)
+	j.track(task)
+	return task, nil
 }
 
 func (dd *defaultDirOp) Shell() error {
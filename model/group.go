@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -27,7 +28,10 @@ type Group interface {
 	Current() Column
 	Shift() bool
 	OpenDir() error
+	OpenDirLoading(item FileItem) Column
+	Contains(Column) bool
 	OpenRoot(root string) error
+	OpenPath(dirs []FileItem, items [][]FileItem)
 	CloseDir() (CloseResult, error)
 	JumpTo(colIdx, fileIdx int) bool
 	Refresh() error
@@ -46,6 +50,7 @@ type LocalGroup struct {
 	path    string
 	old     *old
 	columns []Column
+	views   *ViewSettings
 }
 
 // Path current path
@@ -72,27 +77,42 @@ func (g *LocalGroup) Shift() bool {
 	return true
 }
 
-// OpenDir selected dir
-func (g *LocalGroup) OpenDir() error {
+// selectedDir the selected dir to open
+func (g *LocalGroup) selectedDir() (FileItem, error) {
 	co := g.Current()
 	fi, err := co.CurrentFile()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !fi.IsDir() {
-		nfi, err := LoadFile(fi)
+		nfi, err := LoadFile(context.Background(), fi)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fi = nfi
 	}
 
+	g.leave()
+	return fi, nil
+}
+
+// leave the current column for the dir opened from it
+func (g *LocalGroup) leave() {
+	co := g.Current()
 	co.ClearMark()
 	if co.IsShowDetail() {
 		co.ToggleDetail()
 	}
+}
+
+// OpenDir selected dir
+func (g *LocalGroup) OpenDir() error {
+	fi, err := g.selectedDir()
+	if err != nil {
+		return err
+	}
 
-	cc, err := NewLocalColumn(fi)
+	cc, err := NewLocalColumn(fi, g.views)
 	if err != nil {
 		return err
 	}
@@ -102,9 +122,29 @@ func (g *LocalGroup) OpenDir() error {
 	return nil
 }
 
+// OpenDirLoading open item with a loading column, the items should be read by caller
+// item is the selected dir or the dir loaded from the selected file by LoadFile
+func (g *LocalGroup) OpenDirLoading(item FileItem) Column {
+	g.leave()
+	cc := NewLoadingColumn(item, g.views)
+	g.path = item.Path()
+	g.columns = append(g.columns, cc)
+	return cc
+}
+
+// Contains if co is one of the columns
+func (g *LocalGroup) Contains(co Column) bool {
+	for _, v := range g.columns {
+		if v == co {
+			return true
+		}
+	}
+	return false
+}
+
 // OpenRoot open path in first column
 func (g *LocalGroup) OpenRoot(root string) error {
-	item, err := Load(root)
+	item, err := Load(context.Background(), root)
 	if err != nil {
 		return err
 	}
@@ -123,6 +163,43 @@ func (g *LocalGroup) OpenRoot(root string) error {
 	return nil
 }
 
+// PathDirs load the dir of path and its parents, up to depth dirs, the parents are before it
+// path can be a loader path such as /a/b.zip@zip:///dir, the parent of an archive is the dir of the archive file
+// the loading stops when ctx is done
+func PathDirs(ctx context.Context, path string, depth int) ([]FileItem, error) {
+	item, err := Load(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if !item.IsDir() {
+		return nil, fmt.Errorf("path: %s is not a dir", path)
+	}
+
+	dirs := []FileItem{item}
+	for len(dirs) < depth {
+		parent, err := dirs[0].(Op).Dir()
+		if err != nil || parent.Path() == dirs[0].Path() {
+			break
+		}
+		dirs = append([]FileItem{parent}, dirs...)
+	}
+	return dirs, nil
+}
+
+// OpenPath open dirs loaded by PathDirs in the columns with their items, the last one is the current column
+func (g *LocalGroup) OpenPath(dirs []FileItem, items [][]FileItem) {
+	columns := make([]Column, len(dirs))
+	for i, v := range dirs {
+		if i > 0 {
+			columns[i-1].SelectByName(v.Name())
+		}
+		columns[i] = NewLoadingColumn(v, g.views)
+		columns[i].Loaded(items[i], false)
+	}
+	g.columns = columns
+	g.path = dirs[len(dirs)-1].Path()
+}
+
 // CloseDir close current dir
 func (g *LocalGroup) CloseDir() (CloseResult, error) {
 	file := g.Current().File()
@@ -201,9 +278,9 @@ func (g *LocalGroup) Restore() {
 	g.Current().SelectByName(old.current)
 }
 
-// NewLocalGroup create local group
-func NewLocalGroup(path string) (Group, error) {
-	fi, err := Load(path)
+// NewLocalGroup create local group, the columns are displayed by the settings in views
+func NewLocalGroup(path string, views *ViewSettings) (Group, error) {
+	fi, err := Load(context.Background(), path)
 	if err != nil {
 		return nil, err
 	}
@@ -211,9 +288,9 @@ func NewLocalGroup(path string) (Group, error) {
 		return nil, fmt.Errorf("path: %s is not dir", path)
 	}
 
-	co, err := NewLocalColumn(fi)
+	co, err := NewLocalColumn(fi, views)
 	if err != nil {
 		return nil, err
 	}
-	return &LocalGroup{path, nil, []Column{co}}, nil
+	return &LocalGroup{path, nil, []Column{co}, views}, nil
 }
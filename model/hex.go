@@ -0,0 +1,233 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	hexBlockSize  = 64 * kb
+	hexCacheCount = 64
+)
+
+var (
+	// ErrNotFound search pattern not found
+	ErrNotFound = errors.New("not found")
+
+	errSearchCanceled = errors.New("search canceled")
+	errReadCanceled   = errors.New("read canceled")
+)
+
+// HexFile random access to a file for hex view
+// If the underground reader is not a io.ReaderAt (file inside archive, ssh file),
+// it is read as a stream, only blocks around the view are cached.
+// Reading backward beyond the cache reopens the stream.
+// It is not safe for concurrent use.
+type HexFile struct {
+	ctx    context.Context
+	item   FileItem
+	reader io.ReadCloser
+	at     io.ReaderAt
+	pos    int64
+	blocks map[int64][]byte
+	order  []int64
+}
+
+// OpenHex open item for hex view, the reader of item is closed when ctx is done
+func OpenHex(ctx context.Context, item FileItem) (*HexFile, error) {
+	op, ok := item.(FileOp)
+	if !ok || item.IsDir() {
+		return nil, errors.New("can not view " + item.Name())
+	}
+
+	r, err := op.Reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hf := &HexFile{ctx, item, r, nil, 0, make(map[int64][]byte), nil}
+	if at, ok := r.(io.ReaderAt); ok {
+		hf.at = at
+	}
+	return hf, nil
+}
+
+// Item the file in view
+func (hf *HexFile) Item() FileItem {
+	return hf.item
+}
+
+// Size of the file
+func (hf *HexFile) Size() int64 {
+	return hf.item.Size()
+}
+
+func (hf *HexFile) cache(idx int64, data []byte) {
+	if _, ok := hf.blocks[idx]; ok {
+		return
+	}
+	if len(hf.order) >= hexCacheCount {
+		delete(hf.blocks, hf.order[0])
+		hf.order = hf.order[1:]
+	}
+	hf.blocks[idx] = data
+	hf.order = append(hf.order, idx)
+}
+
+func (hf *HexFile) reopen() error {
+	hf.reader.Close()
+	r, err := hf.item.(FileOp).Reader(hf.ctx)
+	if err != nil {
+		return err
+	}
+	hf.reader = r
+	hf.pos = 0
+	return nil
+}
+
+// block read the idx-th block, a short block means it is the last one
+// the blocks before it are read from the stream, the reading stops when ctx is done
+func (hf *HexFile) block(ctx context.Context, idx int64) ([]byte, error) {
+	if b, ok := hf.blocks[idx]; ok {
+		return b, nil
+	}
+
+	start := idx * hexBlockSize
+	if start < hf.pos {
+		if err := hf.reopen(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return nil, errReadCanceled
+		}
+
+		buf := make([]byte, hexBlockSize)
+		n, err := io.ReadFull(hf.reader, buf)
+		current := hf.pos / hexBlockSize
+		hf.pos += int64(n)
+		if n > 0 {
+			hf.cache(current, buf[:n])
+		}
+		if current == idx {
+			if err == io.ErrUnexpectedEOF || err == io.EOF {
+				err = nil
+			}
+			return buf[:n], err
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReadAt implements io.ReaderAt
+func (hf *HexFile) ReadAt(p []byte, off int64) (int, error) {
+	return hf.ReadAtContext(context.Background(), p, off)
+}
+
+// ReadAtContext read len(p) bytes at off like ReadAt, a stream is read until ctx is done
+func (hf *HexFile) ReadAtContext(ctx context.Context, p []byte, off int64) (int, error) {
+	if hf.at != nil {
+		return hf.at.ReadAt(p, off)
+	}
+
+	read := 0
+	for read < len(p) {
+		pos := off + int64(read)
+		b, err := hf.block(ctx, pos/hexBlockSize)
+		if err != nil {
+			return read, err
+		}
+		from := int(pos % hexBlockSize)
+		if from >= len(b) {
+			return read, io.EOF
+		}
+		read += copy(p[read:], b[from:])
+		if len(b) < hexBlockSize && read < len(p) {
+			return read, io.EOF
+		}
+	}
+	return read, nil
+}
+
+// Search pattern from offset from, the search stops when ctx is done
+func (hf *HexFile) Search(ctx context.Context, pattern []byte, from int64) (int64, error) {
+	if len(pattern) == 0 {
+		return -1, errors.New("empty pattern")
+	}
+
+	buf := make([]byte, hexBlockSize+len(pattern)-1)
+	for off := from; ; off += hexBlockSize {
+		if ctx.Err() != nil {
+			return -1, errSearchCanceled
+		}
+
+		n, err := hf.ReadAtContext(ctx, buf, off)
+		if ctx.Err() != nil {
+			return -1, errSearchCanceled
+		}
+		if idx := bytes.Index(buf[:n], pattern); idx != -1 {
+			return off + int64(idx), nil
+		}
+		if err == io.EOF || n < len(buf) {
+			return -1, ErrNotFound
+		}
+		if err != nil {
+			return -1, err
+		}
+	}
+}
+
+// Close the underground reader
+func (hf *HexFile) Close() error {
+	hf.blocks = nil
+	hf.order = nil
+	return hf.reader.Close()
+}
+
+// ParsePattern parse search pattern, x:7f454c46 or x:7f 45 4c 46 for bytes, others are text
+func ParsePattern(str string) ([]byte, error) {
+	if !strings.HasPrefix(str, "x:") {
+		return []byte(str), nil
+	}
+	return hex.DecodeString(strings.Replace(str[2:], " ", "", -1))
+}
+
+// ParseOffset parse an offset, decimal or hex(0x..), +n or -n is relative to current
+func ParseOffset(str string, current int64) (int64, error) {
+	str = strings.TrimSpace(str)
+	if len(str) == 0 {
+		return current, errors.New("empty offset")
+	}
+
+	sign := int64(0)
+	switch str[0] {
+	case '+':
+		sign = 1
+		str = str[1:]
+	case '-':
+		sign = -1
+		str = str[1:]
+	}
+
+	n, err := strconv.ParseInt(str, 0, 64)
+	if err != nil {
+		return current, err
+	}
+
+	if sign != 0 {
+		return current + sign*n, nil
+	}
+	return n, nil
+}
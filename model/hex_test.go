@@ -0,0 +1,106 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// streamItem a file can only be read as a stream, like a file inside tar
+type streamItem struct {
+	FileItem
+	FileOp
+	data  []byte
+	opens int
+}
+
+func (s *streamItem) Name() string { return "stream" }
+func (s *streamItem) Size() int64  { return int64(len(s.data)) }
+func (s *streamItem) IsDir() bool  { return false }
+
+func (s *streamItem) Reader(context.Context) (io.ReadCloser, error) {
+	s.opens++
+	return ioutil.NopCloser(bytes.NewBuffer(s.data)), nil
+}
+
+func TestHexFileStream(t *testing.T) {
+	data := make([]byte, hexBlockSize*(hexCacheCount+3)+100)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	copy(data[hexBlockSize-2:], "needle")
+	copy(data[len(data)-6:], "needle")
+
+	item := &streamItem{data: data}
+	hf, err := OpenHex(context.Background(), item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hf.Close()
+
+	buf := make([]byte, 300)
+	for _, off := range []int64{0, hexBlockSize - 10, int64(len(data)) - 300, 10} {
+		n, err := hf.ReadAt(buf, off)
+		if err != nil || n != len(buf) || !bytes.Equal(buf, data[off:off+300]) {
+			t.Errorf("read at %d: n %d, err %v", off, n, err)
+		}
+	}
+	if item.opens != 2 {
+		t.Errorf("expect the stream to be reopened once, opened %d times", item.opens)
+	}
+
+	n, err := hf.ReadAt(buf, int64(len(data))-100)
+	if n != 100 || err != io.EOF {
+		t.Errorf("read the tail: n %d, err %v", n, err)
+	}
+
+	off, err := hf.Search(context.Background(), []byte("needle"), 0)
+	if err != nil || off != hexBlockSize-2 {
+		t.Errorf("search across blocks: got %d, %v", off, err)
+	}
+	off, err = hf.Search(context.Background(), []byte("needle"), off+1)
+	if err != nil || off != int64(len(data))-6 {
+		t.Errorf("search the tail: got %d, %v", off, err)
+	}
+	if _, err = hf.Search(context.Background(), []byte("needle"), off+1); err != ErrNotFound {
+		t.Errorf("expect not found, got %v", err)
+	}
+
+	// the stream is not read once canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fresh, _ := OpenHex(context.Background(), &streamItem{data: data})
+	if n, err = fresh.ReadAtContext(ctx, buf, hexBlockSize*2); n != 0 || err != errReadCanceled {
+		t.Errorf("read canceled: n %d, err %v", n, err)
+	}
+	if _, err = fresh.Search(ctx, []byte("needle"), 0); err != errSearchCanceled {
+		t.Errorf("search canceled: %v", err)
+	}
+}
+
+func TestParseHexInput(t *testing.T) {
+	bs, err := ParsePattern("x:7f 45 4c46")
+	if err != nil || !bytes.Equal(bs, []byte{0x7f, 'E', 'L', 'F'}) {
+		t.Errorf("hex pattern: %v %v", bs, err)
+	}
+	if bs, _ = ParsePattern("ELF"); string(bs) != "ELF" {
+		t.Errorf("text pattern: %q", bs)
+	}
+	if _, err = ParsePattern("x:7g"); err == nil {
+		t.Error("expect error for bad hex")
+	}
+
+	cases := []struct {
+		str    string
+		offset int64
+	}{
+		{"4096", 4096}, {"0x1000", 4096}, {"+0x10", 116}, {"-16", 84},
+	}
+	for _, c := range cases {
+		if off, err := ParseOffset(c.str, 100); err != nil || off != c.offset {
+			t.Errorf("%s: expect %d, got %d %v", c.str, c.offset, off, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// historySize the number of finished tasks kept in history
+const historySize = 50
+
+// TaskStatus how a task ended
+type TaskStatus uint8
+
+// Task status
+const (
+	TaskCompleted TaskStatus = iota
+	TaskCanceled
+	TaskFailed
+)
+
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskCompleted:
+		return "completed"
+	case TaskCanceled:
+		return "canceled"
+	default:
+		return "failed"
+	}
+}
+
+// TaskError an error of task, Task is the item failed in a batch
+type TaskError struct {
+	Task Task
+	Err  error
+}
+
+func (te *TaskError) Error() string {
+	return te.Err.Error()
+}
+
+// TaskRecord a submitted task and how it ended, it is safe for concurrent use
+type TaskRecord struct {
+	task     Task
+	started  time.Time
+	finished time.Time
+	bytes    int64
+	canceled bool
+	errors   []*TaskError
+	lock     *sync.Mutex
+}
+
+func newTaskRecord(task Task) *TaskRecord {
+	return &TaskRecord{task, time.Now(), time.Time{}, -1, false, nil, new(sync.Mutex)}
+}
+
+func (r *TaskRecord) addError(err error) {
+	te, ok := err.(*TaskError)
+	if !ok {
+		te = &TaskError{r.task, err}
+	}
+
+	r.lock.Lock()
+	r.errors = append(r.errors, te)
+	r.lock.Unlock()
+}
+
+func (r *TaskRecord) cancel() {
+	r.lock.Lock()
+	r.canceled = true
+	r.lock.Unlock()
+}
+
+func (r *TaskRecord) finish() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.finished = time.Now()
+	if m := r.task.Meter(); m != nil {
+		r.bytes = m.Done()
+	}
+}
+
+// Task the recorded task
+func (r *TaskRecord) Task() Task {
+	return r.task
+}
+
+// Started when the task is submitted
+func (r *TaskRecord) Started() time.Time {
+	return r.started
+}
+
+// Duration how long the task ran
+func (r *TaskRecord) Duration() time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.finished.IsZero() {
+		return time.Since(r.started)
+	}
+	return r.finished.Sub(r.started)
+}
+
+// Bytes transferred, -1 if the task does not transfer bytes
+func (r *TaskRecord) Bytes() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.bytes
+}
+
+// Errors of the task, one for each failed item
+func (r *TaskRecord) Errors() []*TaskError {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*TaskError(nil), r.errors...)
+}
+
+// Status of the task, a canceled task is canceled even if some items failed before
+func (r *TaskRecord) Status() TaskStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	switch {
+	case r.canceled:
+		return TaskCanceled
+	case len(r.errors) > 0:
+		return TaskFailed
+	default:
+		return TaskCompleted
+	}
+}
+
+// Retry create a task doing the failed items again, nil if no failed item can be retried
+func (r *TaskRecord) Retry() Task {
+	ts := make([]Task, 0)
+	seen := make(map[Task]bool)
+	for _, v := range r.Errors() {
+		if seen[v.Task] {
+			continue
+		}
+		seen[v.Task] = true
+		if t := v.Task.Retry(); t != nil {
+			ts = append(ts, t)
+		}
+	}
+
+	if len(ts) == 0 {
+		return nil
+	}
+	if _, ok := r.task.(BatchTask); !ok {
+		return ts[0]
+	}
+	return NewBatchTask(r.task.Name(), ts)
+}
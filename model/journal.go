@@ -0,0 +1,350 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// the journal of a running copy is saved once in journalInterval
+	journalInterval = 2 * time.Second
+
+	// a journal saved in this duration by a running fff belongs to a copy still running
+	journalLive = 3 * journalInterval
+
+	// the size of the blocks compared with the source before a copy is resumed
+	resumeBlockSize = 1024 * 1024
+)
+
+// journalDir where the journals are saved, nothing is saved if it is empty
+var journalDir string
+
+// journalEntry a file to copy, done is read from meter when the journal is saved
+// owned means the target is created by the copy or the user agreed to override it,
+// a target not owned is not overridden without asking again
+type journalEntry struct {
+	source, target string
+	size, done     int64
+	meter          *Meter
+	finished       bool
+	owned          bool
+}
+
+// Journal the pending files of a copy to a local dir, it is saved in the config dir while the copy runs,
+// so that the copy can be resumed after fff is restarted
+//
+// A line of the journal file is: size done source target owned, separated by tab, owned is 1 or 0.
+// The pid of the fff running the copy is saved in a comment line: # pid 123
+type Journal struct {
+	path    string
+	pid     int
+	entries []*journalEntry
+	lock    *sync.Mutex
+}
+
+func newJournal() *Journal {
+	name := strconv.FormatInt(time.Now().UnixNano(), 10)
+	return &Journal{filepath.Join(journalDir, name), os.Getpid(), nil, new(sync.Mutex)}
+}
+
+// processAlive if the process of pid is running
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || os.IsPermission(err)
+}
+
+// isLive if the copy of the journal is still running in a fff, it saves the journal in every journalInterval.
+// The modify time is checked too, so that a pid reused after fff was killed does not keep the journal
+func (j *Journal) isLive(modTime time.Time) bool {
+	return j.pid > 0 && time.Since(modTime) < journalLive && processAlive(j.pid)
+}
+
+// PendingJournals the journals left by the copies not finished before fff quit,
+// the journals of the copies still running in other fff are left out
+func PendingJournals() []*Journal {
+	fis, err := ioutil.ReadDir(journalDir)
+	if err != nil {
+		return nil
+	}
+
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+	re := make([]*Journal, 0)
+	for _, v := range fis {
+		j, err := readJournal(filepath.Join(journalDir, v.Name()))
+		if err != nil || len(j.entries) == 0 {
+			os.Remove(filepath.Join(journalDir, v.Name()))
+			continue
+		}
+		if j.isLive(v.ModTime()) {
+			continue
+		}
+		re = append(re, j)
+	}
+	return re
+}
+
+func readJournal(path string) (*Journal, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &Journal{path, 0, nil, new(sync.Mutex)}
+	for _, line := range strings.Split(string(bs), "\n") {
+		if strings.HasPrefix(line, "# pid ") {
+			j.pid, _ = strconv.Atoi(line[len("# pid "):])
+			continue
+		}
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		ts := strings.Split(line, "\t")
+		if len(ts) != 4 && len(ts) != 5 {
+			return nil, fmt.Errorf("%s: illegal journal line: %s", path, line)
+		}
+		size, err := strconv.ParseInt(ts[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		done, err := strconv.ParseInt(ts[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		owned := len(ts) == 5 && ts[4] == "1"
+		j.entries = append(j.entries, &journalEntry{ts[2], ts[3], size, done, nil, false, owned})
+	}
+	return j, nil
+}
+
+func (j *Journal) add(source, target string, size int64, meter *Meter) *journalEntry {
+	e := &journalEntry{source, target, size, 0, meter, false, false}
+	j.entries = append(j.entries, e)
+	return e
+}
+
+// finish e, a copied or skipped file is not resumed
+func (j *Journal) finish(e *journalEntry) {
+	j.lock.Lock()
+	e.finished = true
+	j.lock.Unlock()
+}
+
+// own the target of e before it is written, it is created by the copy or the user agreed to override it
+func (j *Journal) own(e *journalEntry) {
+	j.lock.Lock()
+	e.owned = true
+	j.lock.Unlock()
+}
+
+func (j *Journal) save() {
+	if journalDir == "" {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	s := fmt.Sprintf("# fff unfinished copy: size done source target owned\n# pid %d\n", os.Getpid())
+	for _, e := range j.entries {
+		if e.finished {
+			continue
+		}
+		if e.meter != nil {
+			e.done = e.meter.Done()
+		}
+		owned := 0
+		if e.owned {
+			owned = 1
+		}
+		s = fmt.Sprintf("%s%d\t%d\t%s\t%s\t%d\n", s, e.size, e.done, e.source, e.target, owned)
+	}
+
+	if _, err := os.Stat(journalDir); err != nil {
+		os.MkdirAll(journalDir, 0755)
+	}
+	ioutil.WriteFile(j.path, []byte(s), 0644)
+}
+
+// Discard the journal, its files are not going to be resumed
+func (j *Journal) Discard() {
+	if journalDir == "" {
+		return
+	}
+	os.Remove(j.path)
+}
+
+// track save j while task runs, j is discarded when task ends, so only a copy interrupted by a quit leaves it
+func (j *Journal) track(task Task) {
+	j.save()
+	stop, stopped := make(chan bool), make(chan bool)
+	task.Attach(NewListener(nil, func() {
+		close(stop)
+		<-stopped
+	}))
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(journalInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.save()
+			case <-stop:
+				j.Discard()
+				return
+			}
+		}
+	}()
+}
+
+// String a summary of the journal
+func (j *Journal) String() string {
+	var size, done int64
+	for _, e := range j.entries {
+		size += e.size
+		done += e.done
+	}
+	target := filepath.Dir(j.entries[0].target)
+	return fmt.Sprintf("%d files to %s, %d of %d bytes done", len(j.entries), target, done, size)
+}
+
+// Resume create a task copying the pending files, each file continues from the size of its target
+// a target existed before the copy is asked again before it is overridden
+func (j *Journal) Resume() Task {
+	tasks := make([]Task, len(j.entries))
+	for i, v := range j.entries {
+		e := v
+		tasks[i] = NewTransferTask(filepath.Base(e.target), e.size, func(ctx context.Context, meter *Meter, progress chan<- int, eh chan<- error) {
+			defer close(progress)
+			defer close(eh)
+
+			if _, err := os.Stat(e.target); err == nil && !e.owned {
+				answer := askInTask(fmt.Sprintf("%s is already exists, override it? (y/n)", e.target))
+				if answer != "y" {
+					j.finish(e)
+					return
+				}
+			}
+			j.own(e)
+
+			if err := resumeFile(ctx, e.source, e.target, meter, progress); err != nil {
+				reportError(ctx, eh, err)
+				return
+			}
+			j.finish(e)
+		})
+		e.meter = tasks[i].Meter()
+	}
+
+	task := NewBatchTask("Resume", tasks)
+	j.track(task)
+	return task
+}
+
+// resumeOffset where to continue copying r to w, -1 if w is not a prefix of r. r is moved to the offset if it is not -1
+// The whole copied part is compared with the source, a stream (file in archive, ssh file) has to be read
+// to the offset anyway, the comparing stops when ctx is done
+func resumeOffset(ctx context.Context, r io.Reader, w *os.File, size int64) (int64, error) {
+	fi, err := w.Stat()
+	if err != nil {
+		return 0, err
+	}
+	off := fi.Size()
+	if off == 0 {
+		return 0, nil
+	}
+	if off > size {
+		return -1, nil
+	}
+
+	src, dst := make([]byte, resumeBlockSize), make([]byte, resumeBlockSize)
+	for pos := int64(0); pos < off; {
+		if err := waitResumed(ctx); err != nil {
+			return 0, err
+		}
+
+		n := int64(resumeBlockSize)
+		if off-pos < n {
+			n = off - pos
+		}
+		if _, err := io.ReadFull(r, src[:n]); err != nil {
+			return 0, err
+		}
+		if _, err := w.ReadAt(dst[:n], pos); err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(src[:n], dst[:n]) {
+			return -1, nil
+		}
+		pos += n
+	}
+	return off, nil
+}
+
+// resumeFile copy source to target from the verified size of target, or from start if it is not verified
+func resumeFile(ctx context.Context, source, target string, meter *Meter, progress chan<- int) error {
+	ctx = throughLoaders(ctx, source, target)
+	item, err := Load(ctx, source)
+	if err != nil {
+		return err
+	}
+	op, ok := item.(FileOp)
+	if !ok || item.IsDir() {
+		return fmt.Errorf("%s is not a file", source)
+	}
+
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+	w, err := os.OpenFile(target, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	r, err := op.Reader(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { r.Close() }()
+
+	off, err := resumeOffset(ctx, r, w, item.Size())
+	if err != nil {
+		return err
+	}
+	if off == -1 {
+		r.Close()
+		r, err = op.Reader(ctx)
+		if err != nil {
+			return err
+		}
+		off = 0
+	}
+
+	if err := w.Truncate(off); err != nil {
+		return err
+	}
+	if _, err := w.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+	meter.Skip(off)
+	if err := copyData(ctx, w, r, meter, progress); err != nil {
+		return err
+	}
+	return checkCopy(ctx, item, func() (FileItem, error) { return Load(ctx, target) })
+}
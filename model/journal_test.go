@@ -0,0 +1,146 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumeCopy(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-resume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	journalDir = filepath.Join(tmp, "transfers")
+	defer func() { journalDir = "" }()
+
+	src, dst := filepath.Join(tmp, "src"), filepath.Join(tmp, "dst")
+	os.Mkdir(src, 0755)
+	os.Mkdir(dst, 0755)
+	data := make([]byte, 3*resumeBlockSize)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	// a differs from the source in the first block, b in the last one, both are copied again
+	first := append([]byte{'x'}, data[1:2*resumeBlockSize+100]...)
+	last := append([]byte(nil), data[:2*resumeBlockSize]...)
+	last[len(last)-1] = 'x'
+	targets := map[string][]byte{"a": first, "b": last, "c": data, "d": nil, "e": data[:resumeBlockSize+100]}
+
+	j := newJournal()
+	for name, bs := range targets {
+		ioutil.WriteFile(filepath.Join(src, name), data, 0644)
+		if bs != nil {
+			ioutil.WriteFile(filepath.Join(dst, name), bs, 0644)
+		}
+		j.own(j.add(filepath.Join(src, name), filepath.Join(dst, name), int64(len(data)), nil))
+	}
+	// the copy of f did not start, its target existed before is not overridden unless agreed
+	ioutil.WriteFile(filepath.Join(src, "f"), data, 0644)
+	ioutil.WriteFile(filepath.Join(dst, "f"), []byte("mine"), 0644)
+	j.add(filepath.Join(src, "f"), filepath.Join(dst, "f"), int64(len(data)), nil)
+	j.save()
+
+	// the journal is saved just now by this process, as if the copy is still running
+	if js := PendingJournals(); len(js) != 0 {
+		t.Fatal("the journal of a running copy should not be pending")
+	}
+
+	old := time.Now().Add(-2 * journalLive)
+	os.Chtimes(j.path, old, old)
+	js := PendingJournals()
+	if len(js) != 1 || len(js[0].entries) != len(targets)+1 {
+		t.Fatalf("expect the saved journal to be pending, got %v", js)
+	}
+
+	task := js[0].Resume()
+	tm := NewTaskManager()
+	done := make(chan bool)
+	tm.Attach(NewTaskListener(nil, func(Task) { close(done) }, nil))
+	asked := make(chan string, 1)
+	go func() {
+		req := <-RequestCh
+		asked <- req.Title
+		req.Answer("n")
+	}()
+	errs := tm.Submit(task)
+	for err := range errs {
+		t.Error(err)
+	}
+	<-done
+
+	if title := <-asked; !strings.Contains(title, filepath.Join(dst, "f")) {
+		t.Errorf("expect to be asked for f, got %s", title)
+	}
+	if bs, _ := ioutil.ReadFile(filepath.Join(dst, "f")); string(bs) != "mine" {
+		t.Errorf("f is overridden without asking: %q", bs)
+	}
+
+	for name := range targets {
+		bs, _ := ioutil.ReadFile(filepath.Join(dst, name))
+		if !bytes.Equal(bs, data) {
+			t.Errorf("%s is not copied correctly", name)
+		}
+	}
+
+	if m := task.Meter(); m.Done() != int64(len(targets)*len(data)) {
+		t.Errorf("resumed %d of %d bytes", m.Done(), m.Total())
+	}
+	if js := PendingJournals(); len(js) != 0 {
+		t.Error("the journal should be removed after the copy")
+	}
+}
+
+func TestResumeOffset(t *testing.T) {
+	f, err := ioutil.TempFile("", "fff-resume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	data := make([]byte, 2*resumeBlockSize+100)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	cases := []struct {
+		target []byte
+		off    int64
+	}{
+		{nil, 0},
+		{data[:resumeBlockSize+10], resumeBlockSize + 10},
+		{append([]byte{'x'}, data[1:resumeBlockSize+10]...), -1},
+		{append(append([]byte(nil), data[:resumeBlockSize+9]...), 'x'), -1},
+		{append(append([]byte(nil), data...), 'x'), -1},
+	}
+	for i, c := range cases {
+		f.Truncate(0)
+		f.WriteAt(c.target, 0)
+
+		r := bytes.NewReader(data)
+		off, err := resumeOffset(context.Background(), r, f, int64(len(data)))
+		if err != nil || off != c.off {
+			t.Errorf("%d: expect offset %d, got %d %v", i, c.off, off, err)
+			continue
+		}
+		if rest := int64(r.Len()); off != -1 && rest != int64(len(data))-off {
+			t.Errorf("%d: the source should be read to %d, %d left", i, off, rest)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f.Truncate(0)
+	f.WriteAt(data, 0)
+	if _, err := resumeOffset(ctx, bytes.NewReader(data), f, int64(len(data))); err != context.Canceled {
+		t.Errorf("expect the compare to be canceled, got %v", err)
+	}
+}
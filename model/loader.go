@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -13,12 +14,14 @@ type Loader interface {
 	Name() string
 	Seperator() string
 	Support(FileItem) bool
-	Create(FileItem) (FileItem, error)
+	Create(context.Context, FileItem) (FileItem, error)
 }
 
 var (
 	loaders   []Loader
 	loaderMap = make(map[string]Loader)
+
+	loaderToken = regexp.MustCompile(`@(\w+)://`)
 )
 
 func init() {
@@ -42,10 +45,10 @@ type localLoader struct {
 	root FileItem
 }
 
-func (fp *localLoader) Name() string                             { return "file" }
-func (fp *localLoader) Seperator() string                        { return string(filepath.Separator) }
-func (fp *localLoader) Support(item FileItem) bool               { return item.IsDir() }
-func (fp *localLoader) Create(parent FileItem) (FileItem, error) { return fp.root, nil }
+func (fp *localLoader) Name() string                                       { return "file" }
+func (fp *localLoader) Seperator() string                                  { return string(filepath.Separator) }
+func (fp *localLoader) Support(item FileItem) bool                         { return item.IsDir() }
+func (fp *localLoader) Create(context.Context, FileItem) (FileItem, error) { return fp.root, nil }
 
 func registerLoader(loader Loader) {
 	loaderMap[loader.Name()] = loader
@@ -57,12 +60,12 @@ type PathItem struct {
 	Loader, Path, Seperator string
 }
 
-// ParsePath parse path
+// ParsePath parse path, it fails if a loader of path is unknown
 // /a/b/c.zip@zip:///hello/path
 // /a/b/c.fff@ssh:///opt/c.tgz@tgz:///hello/path
-func ParsePath(path string) []*PathItem {
+func ParsePath(path string) ([]*PathItem, error) {
 	p := "@file://" + path
-	tokens := regexp.MustCompile(`@(\w+)://`).FindAllStringSubmatchIndex(p, -1)
+	tokens := loaderToken.FindAllStringSubmatchIndex(p, -1)
 	re := make([]*PathItem, 0)
 	for i, v := range tokens {
 		end := len(p)
@@ -70,17 +73,24 @@ func ParsePath(path string) []*PathItem {
 			end = tokens[i+1][0]
 		}
 		name, arg := p[v[2]:v[3]], p[v[1]:end]
-		re = append(re, &PathItem{name, arg, loaderMap[name].Seperator()})
+		loader, ok := loaderMap[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown loader: %s", name)
+		}
+		re = append(re, &PathItem{name, arg, loader.Seperator()})
 	}
-	return re
+	return re, nil
 }
 
-// Load file item from path
-func Load(path string) (FileItem, error) {
+// Load file item from path, the loading stops when ctx is done
+func Load(ctx context.Context, path string) (FileItem, error) {
 	var item FileItem
-	pis := ParsePath(path)
+	pis, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
 	for _, p := range pis {
-		i, err := loaderMap[p.Loader].Create(item)
+		i, err := loaderMap[p.Loader].Create(ctx, item)
 		if err != nil {
 			return nil, err
 		}
@@ -93,11 +103,11 @@ func Load(path string) (FileItem, error) {
 	return item, nil
 }
 
-// LoadFile load a file as dir, such as .tar file
-func LoadFile(item FileItem) (FileItem, error) {
+// LoadFile load a file as dir, such as .tar file, the loading stops when ctx is done
+func LoadFile(ctx context.Context, item FileItem) (FileItem, error) {
 	for _, v := range loaders {
 		if v.Support(item) {
-			return v.Create(item)
+			return v.Create(ctx, item)
 		}
 	}
 	return nil, errors.New("can not open file")
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// the rate is sampled once in rateInterval, the progress is refreshed as often during a transfer
+const rateInterval = 500 * time.Millisecond
+
+// Meter count the bytes of a transfer, the bytes are added to its parent too
+// the rate is a moving average of the recent samples, it is safe for concurrent use
+type Meter struct {
+	total    int64
+	done     int64
+	rate     float64
+	last     time.Time
+	lastDone int64
+	parent   *Meter
+	lock     *sync.Mutex
+}
+
+func newMeter(total int64) *Meter {
+	return &Meter{total, 0, 0, time.Time{}, 0, nil, new(sync.Mutex)}
+}
+
+// attach m to parent, the total of m is counted in parent
+func (m *Meter) attach(parent *Meter) {
+	m.lock.Lock()
+	m.parent = parent
+	total := m.total
+	m.lock.Unlock()
+
+	parent.lock.Lock()
+	parent.total += total
+	parent.lock.Unlock()
+}
+
+// Add n transferred bytes
+func (m *Meter) Add(n int64) {
+	m.lock.Lock()
+	now := time.Now()
+	if m.last.IsZero() {
+		m.last = now
+	}
+	m.done += n
+	if d := now.Sub(m.last); d >= rateInterval {
+		r := float64(m.done-m.lastDone) / d.Seconds()
+		if m.rate == 0 {
+			m.rate = r
+		} else {
+			m.rate = m.rate*0.7 + r*0.3
+		}
+		m.last, m.lastDone = now, m.done
+	}
+	parent := m.parent
+	m.lock.Unlock()
+
+	if parent != nil {
+		parent.Add(n)
+	}
+}
+
+// Skip n bytes done before the transfer started, such as the resumed part of a file
+// they are not counted in the rate
+func (m *Meter) Skip(n int64) {
+	m.lock.Lock()
+	m.done += n
+	m.lastDone += n
+	parent := m.parent
+	m.lock.Unlock()
+
+	if parent != nil {
+		parent.Skip(n)
+	}
+}
+
+// Drop the bytes that will not be transferred, such as a skipped or failed file
+func (m *Meter) Drop() {
+	m.lock.Lock()
+	left := m.total - m.done
+	m.total = m.done
+	parent := m.parent
+	m.lock.Unlock()
+
+	if parent != nil && left > 0 {
+		parent.lock.Lock()
+		parent.total -= left
+		parent.lock.Unlock()
+	}
+}
+
+// Total bytes to transfer
+func (m *Meter) Total() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.total
+}
+
+// Done bytes transferred
+func (m *Meter) Done() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.done
+}
+
+// Percent of the transferred bytes, an empty transfer is done
+func (m *Meter) Percent() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.total <= 0 || m.done >= m.total {
+		return 100
+	}
+	return int(m.done * 100 / m.total)
+}
+
+// Rate bytes per second, 0 if it is not known yet
+// it goes down if nothing is transferred for a while
+func (m *Meter) Rate() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.last.IsZero() {
+		return 0
+	}
+	if d := time.Since(m.last); d >= 2*rateInterval {
+		return float64(m.done-m.lastDone) / d.Seconds()
+	}
+	return m.rate
+}
+
+// ETA the estimated time left, -1 if it is not known
+func (m *Meter) ETA() time.Duration {
+	rate := m.Rate()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if rate <= 0 {
+		return -1
+	}
+	return time.Duration(float64(m.total-m.done) / rate * float64(time.Second))
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBatchTransferBytes(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-meter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	src, dst := filepath.Join(tmp, "src"), filepath.Join(tmp, "dst")
+	os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	os.Mkdir(dst, 0755)
+	ioutil.WriteFile(filepath.Join(src, "big"), make([]byte, 1<<20), 0644)
+	for i := 0; i < 9; i++ {
+		ioutil.WriteFile(filepath.Join(src, "sub", fmt.Sprintf("small-%d", i)), []byte("tiny"), 0644)
+	}
+
+	from, _ := Load(context.Background(), src)
+	to, _ := Load(context.Background(), dst)
+	task, err := to.(DirOp).Write([]FileItem{from})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the bytes of all files are known before the copy starts
+	m := task.Meter()
+	if m == nil || m.Total() != 1<<20+9*4 || m.Done() != 0 {
+		t.Fatalf("total bytes are not counted up front: %v", m)
+	}
+
+	tm := NewTaskManager()
+	done := make(chan bool)
+	tm.Attach(NewTaskListener(nil, func(Task) { close(done) }, nil))
+	tm.Submit(task)
+	<-done
+
+	if m.Done() != m.Total() || m.Percent() != 100 {
+		t.Errorf("copied %d of %d bytes", m.Done(), m.Total())
+	}
+}
+
+func TestMeterDrop(t *testing.T) {
+	parent := newMeter(0)
+	a, b := newMeter(100), newMeter(300)
+	a.attach(parent)
+	b.attach(parent)
+
+	a.Add(100)
+	b.Add(50)
+	if parent.Total() != 400 || parent.Done() != 150 || parent.Percent() != 37 {
+		t.Errorf("unexpected parent %d/%d", parent.Done(), parent.Total())
+	}
+	if parent.ETA() != -1 {
+		t.Error("the eta is not known before the first sample")
+	}
+
+	// a skipped file is not waited for
+	b.Drop()
+	if parent.Total() != 150 || parent.Percent() != 100 {
+		t.Errorf("dropped bytes are still counted %d/%d", parent.Done(), parent.Total())
+	}
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DetectMime the mime type of item sniffed from its first 512 bytes, without the parameters such as charset
+// the type of the extension is used if the content is not recognized, empty if item can not be read
+func DetectMime(ctx context.Context, item FileItem) string {
+	op, ok := item.(FileOp)
+	if !ok || item.IsDir() {
+		return ""
+	}
+	r, err := op.Reader(ctx)
+	if err != nil {
+		return ""
+	}
+	defer r.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+
+	mt := http.DetectContentType(buf[:n])
+	if mt == "application/octet-stream" {
+		if et := mime.TypeByExtension(filepath.Ext(item.Name())); et != "" {
+			mt = et
+		}
+	}
+	if i := strings.Index(mt, ";"); i != -1 {
+		mt = mt[:i]
+	}
+	return strings.TrimSpace(mt)
+}
+
+// LoaderName the name of the loader item is read by, such as file or ssh
+func LoaderName(item FileItem) string {
+	pis, _ := ParsePath(item.Path())
+	return pis[len(pis)-1].Loader
+}
+
+var (
+	fetchedLock = new(sync.Mutex)
+	fetchedDirs []string
+)
+
+// NewFetchTask create a task copying item to the local file system, it is shown as a transfer
+// a file in an archive or on a ssh server is copied to a temp dir, it has the same name so that the programs
+// can tell its type. fetched is called with the local path when the copy is done, a local file is not copied
+func NewFetchTask(item FileItem, fetched func(string)) Task {
+	return NewTransferTask(item.Name(), item.Size(), func(ctx context.Context, meter *Meter, progress chan<- int, eh chan<- error) {
+		defer close(progress)
+		defer close(eh)
+
+		if LoaderName(item) == "file" {
+			meter.Skip(item.Size())
+			fetched(item.Path())
+			return
+		}
+
+		path, err := fetch(ctx, item, meter, progress)
+		if err != nil {
+			reportError(ctx, eh, err)
+			return
+		}
+		fetched(path)
+	})
+}
+
+func fetch(ctx context.Context, item FileItem, meter *Meter, progress chan<- int) (string, error) {
+	r, err := item.(FileOp).Reader(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	dir, err := tempDir("open-")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, item.Name())
+	if err = copyTo(ctx, path, r, meter, progress); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	fetchedLock.Lock()
+	fetchedDirs = append(fetchedDirs, dir)
+	fetchedLock.Unlock()
+	return path, nil
+}
+
+func copyTo(ctx context.Context, path string, r io.Reader, meter *Meter, progress chan<- int) error {
+	w, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = copyData(ctx, w, r, meter, progress)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// CleanFetched remove the temp dirs of the fetched files, it is called when fff quits
+func CleanFetched() {
+	fetchedLock.Lock()
+	defer fetchedLock.Unlock()
+	for _, v := range fetchedDirs {
+		os.RemoveAll(v)
+	}
+	fetchedDirs = nil
+}
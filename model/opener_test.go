@@ -0,0 +1,73 @@
+package model
+
+import (
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectMimeAndFetch(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-opener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	zp := filepath.Join(tmp, "a.zip")
+	f, _ := os.Create(zp)
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{"image": "\x89PNG\r\n\x1a\n0000", "note.md": "# hello", "x.pdf": "\x00\x01"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	f.Close()
+
+	for name, expect := range map[string]string{"image": "image/png", "note.md": "text/plain", "x.pdf": "application/pdf"} {
+		item, err := Load(context.Background(), zp+"@zip:///"+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mt := DetectMime(context.Background(), item); mt != expect {
+			t.Errorf("%s: expect %s, got %s", name, expect, mt)
+		}
+		if LoaderName(item) != "zip" {
+			t.Errorf("%s: expect loader zip, got %s", name, LoaderName(item))
+		}
+	}
+
+	fetch := func(item FileItem) string {
+		fetched := make(chan string, 1)
+		tm := NewTaskManager()
+		done := make(chan bool)
+		tm.Attach(NewTaskListener(nil, func(Task) { close(done) }, nil))
+		for err := range tm.Submit(NewFetchTask(item, func(path string) { fetched <- path })) {
+			t.Error(err)
+		}
+		<-done
+		select {
+		case path := <-fetched:
+			return path
+		default:
+			return ""
+		}
+	}
+
+	item, _ := Load(context.Background(), zp+"@zip:///note.md")
+	path := fetch(item)
+	if bs, _ := ioutil.ReadFile(path); filepath.Base(path) != "note.md" || string(bs) != "# hello" {
+		t.Errorf("unexpected copy %s %q", path, bs)
+	}
+	CleanFetched()
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("the temp dir of %s is not removed", path)
+	}
+
+	local, _ := Load(context.Background(), zp)
+	if path := fetch(local); path != zp {
+		t.Errorf("expect the local path %s, got %s", zp, path)
+	}
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"context"
+	"sync"
+)
+
+type pauserKey struct{}
+
+// pauser hold the work of a task while it is paused, it is safe for concurrent use
+type pauser struct {
+	paused  bool
+	changed chan bool
+	lock    *sync.Mutex
+}
+
+func newPauser() *pauser {
+	return &pauser{false, make(chan bool), new(sync.Mutex)}
+}
+
+// pauserOf the pauser of the task running with ctx, nil if there is none
+func pauserOf(ctx context.Context) *pauser {
+	p, _ := ctx.Value(pauserKey{}).(*pauser)
+	return p
+}
+
+func (p *pauser) set(paused bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.paused == paused {
+		return
+	}
+	p.paused = paused
+	close(p.changed)
+	p.changed = make(chan bool)
+}
+
+func (p *pauser) pause()  { p.set(true) }
+func (p *pauser) resume() { p.set(false) }
+
+// state the current state, and a chan closed when it changes
+func (p *pauser) state() (bool, <-chan bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.paused, p.changed
+}
+
+// wait until p is resumed, it returns the error of ctx if ctx is done before that
+func (p *pauser) wait(ctx context.Context) error {
+	if p == nil {
+		return ctx.Err()
+	}
+	for {
+		paused, changed := p.state()
+		if !paused {
+			return ctx.Err()
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitResumed wait until the task running with ctx is resumed
+func waitResumed(ctx context.Context) error {
+	return pauserOf(ctx).wait(ctx)
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// slowReader give a small chunk in each read
+type slowReader struct {
+	left int
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	if s.left == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(time.Millisecond)
+	n := 1024
+	if n > s.left {
+		n = s.left
+	}
+	s.left -= n
+	return n, nil
+}
+
+func TestPauseTransfer(t *testing.T) {
+	size := 200 * 1024
+	sub := NewTransferTask("slow", int64(size), func(ctx context.Context, meter *Meter, progress chan<- int, eh chan<- error) {
+		defer close(progress)
+		defer close(eh)
+		if err := copyData(ctx, ioutil.Discard, &slowReader{size}, meter, progress); err != nil {
+			eh <- err
+		}
+	})
+	task := NewBatchTask("batch", []Task{sub})
+
+	tm := NewTaskManager()
+	done := make(chan bool)
+	tm.Attach(NewTaskListener(nil, func(Task) { close(done) }, nil))
+	tm.Submit(task)
+
+	time.Sleep(20 * time.Millisecond)
+	tm.Pause(task)
+	time.Sleep(20 * time.Millisecond)
+	paused := task.Meter().Done()
+	time.Sleep(50 * time.Millisecond)
+	if !task.IsPaused() || task.Meter().Done() != paused {
+		t.Fatalf("the copy goes on while paused, %d -> %d", paused, task.Meter().Done())
+	}
+
+	tm.Resume(task)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the resumed task is not finished")
+	}
+	if task.Meter().Done() != int64(size) {
+		t.Errorf("copied %d of %d bytes", task.Meter().Done(), size)
+	}
+}
@@ -0,0 +1,203 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// PreviewType the type of preview content
+type PreviewType uint8
+
+// Preview types
+const (
+	PreviewText PreviewType = iota
+	PreviewDir
+	PreviewArchive
+	PreviewBinary
+)
+
+const (
+	previewBytes    = 32 * kb
+	hexBytesPerLine = 8
+)
+
+var errPreviewCanceled = errors.New("preview canceled")
+
+// Preview the content of a file item
+type Preview struct {
+	Item  FileItem
+	Type  PreviewType
+	Lines []string
+	Items []FileItem
+
+	// Encoding of the text file, see DetectEncoding
+	Encoding string
+}
+
+func previewDir(ctx context.Context, item FileItem, lines int) (*Preview, error) {
+	its, err := item.(DirOp).Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byName{its})
+	if len(its) > lines {
+		its = its[:lines]
+	}
+	return &Preview{item, PreviewDir, nil, its, ""}, nil
+}
+
+func archiveLoaderOf(item FileItem) Loader {
+	for _, v := range loaders {
+		switch v.(type) {
+		case *zipLoader, *tarLoader, *tgzLoader:
+			if v.Support(item) {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func previewArchive(ctx context.Context, item FileItem, ld Loader, lines int) (*Preview, error) {
+	root, err := ld.Create(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	ai, ok := root.(archiveItem)
+	if !ok {
+		return nil, errors.New("not an archive")
+	}
+
+	its := ai.archive().items()
+	names := make([]string, 0, len(its))
+	for _, v := range its {
+		name := v.ipath()
+		if v.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > lines {
+		names = names[:lines]
+	}
+	return &Preview{item, PreviewArchive, names, nil, ""}, nil
+}
+
+// readHead read at most n bytes, the reader is closed when ctx is done
+func readHead(ctx context.Context, item FileItem, n int) ([]byte, error) {
+	r, err := item.(FileOp).Reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	defer closeOnDone(ctx, r).Close()
+
+	buf := make([]byte, n)
+	read := 0
+	for read < n {
+		c, err := r.Read(buf[read:])
+		read += c
+		if ctx.Err() != nil {
+			return nil, errPreviewCanceled
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf[:read], nil
+}
+
+func textLines(s string, lines int) []string {
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	s = strings.Replace(s, "\t", "    ", -1)
+	s = strings.Map(func(r rune) rune {
+		if r != '\n' && unicode.IsControl(r) {
+			return '.'
+		}
+		return r
+	}, s)
+	ls := strings.Split(s, "\n")
+	if len(ls) > lines {
+		ls = ls[:lines]
+	}
+	return ls
+}
+
+func hexLine(offset int, bs []byte) string {
+	hex := make([]string, hexBytesPerLine)
+	ascii := make([]byte, len(bs))
+	for i := range hex {
+		hex[i] = "  "
+		if i < len(bs) {
+			hex[i] = fmt.Sprintf("%02x", bs[i])
+		}
+	}
+	for i, v := range bs {
+		ascii[i] = '.'
+		if v >= 0x20 && v < 0x7f {
+			ascii[i] = v
+		}
+	}
+	return fmt.Sprintf("%08x  %s  |%s|", offset, strings.Join(hex, " "), ascii)
+}
+
+func hexLines(bs []byte, lines int) []string {
+	ls := make([]string, 0, lines)
+	for i := 0; i < len(bs) && len(ls) < lines; i += hexBytesPerLine {
+		end := i + hexBytesPerLine
+		if end > len(bs) {
+			end = len(bs)
+		}
+		ls = append(ls, hexLine(i, bs[i:end]))
+	}
+	return ls
+}
+
+// LoadPreview load at most lines lines of item for preview
+// it returns early with an error when ctx is done
+func LoadPreview(ctx context.Context, item FileItem, lines int) (*Preview, error) {
+	if item.IsDir() {
+		p, err := previewDir(ctx, item, lines)
+		if ctx.Err() != nil {
+			return nil, errPreviewCanceled
+		}
+		return p, err
+	}
+
+	if ld := archiveLoaderOf(item); ld != nil {
+		p, err := previewArchive(ctx, item, ld, lines)
+		if ctx.Err() != nil {
+			return nil, errPreviewCanceled
+		}
+		return p, err
+	}
+
+	// a link to a dir has the op of file too, it is previewed as a dir above
+	if _, ok := item.(FileOp); !ok {
+		return nil, errors.New("can not preview " + item.Name())
+	}
+
+	bs, err := readHead(ctx, item, previewBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bs) == previewBytes {
+		bs = trimPartialChar(bs)
+	}
+	enc := DetectEncoding(bs)
+	if enc == "" {
+		return &Preview{item, PreviewBinary, hexLines(bs, lines), nil, ""}, nil
+	}
+	return &Preview{item, PreviewText, textLines(Decode(bs, enc), lines), nil, enc}, nil
+}
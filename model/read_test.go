@@ -0,0 +1,181 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const millionFiles = 1000000
+
+var (
+	millionDir  string
+	millionOnce = new(sync.Once)
+)
+
+// createMillionDir create a dir with a million empty files, it is shared by benchmarks
+func createMillionDir(b *testing.B) string {
+	millionOnce.Do(func() {
+		tmp, err := ioutil.TempDir("", "fff-million")
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < millionFiles; i++ {
+			f, err := os.Create(filepath.Join(tmp, fmt.Sprintf("file-%07d", i)))
+			if err != nil {
+				os.RemoveAll(tmp)
+				b.Fatal(err)
+			}
+			f.Close()
+		}
+		millionDir = tmp
+	})
+	if millionDir == "" {
+		b.Skip("can not create the dir")
+	}
+	return millionDir
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if millionDir != "" {
+		os.RemoveAll(millionDir)
+	}
+	os.Exit(code)
+}
+
+func TestReadDirBatch(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	count := readBatchSize*2 + 10
+	for i := 0; i < count; i++ {
+		ioutil.WriteFile(filepath.Join(tmp, fmt.Sprintf("f%d", i)), nil, 0644)
+	}
+	os.Symlink(filepath.Join(tmp, "missing"), filepath.Join(tmp, "broken"))
+	count++
+
+	item, _ := Load(context.Background(), tmp)
+	calls := 0
+	items, err := ReadDir(context.Background(), item, time.Second, func(read []FileItem) {
+		calls++
+		if len(read) == 0 || len(read) > count {
+			t.Errorf("unexpected progress of %d items", len(read))
+		}
+	})
+	if err != nil || len(items) != count {
+		t.Fatalf("expect %d items, got %d, %v", count, len(items), err)
+	}
+	if calls == 0 {
+		t.Error("progress is not called")
+	}
+
+	for _, v := range items {
+		if v.Name() != "broken" {
+			continue
+		}
+		ln, ok := v.Link()
+		if !ok || !ln.IsBroken() || v.IsDir() {
+			t.Error("broken link is not detected")
+		}
+		if _, ok := v.(FileOp); !ok {
+			t.Error("link to file should be a FileOp")
+		}
+	}
+}
+
+func TestLinkToDir(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	os.MkdirAll(filepath.Join(tmp, "sub", "inner"), 0755)
+	os.Symlink(filepath.Join(tmp, "sub"), filepath.Join(tmp, "ln"))
+
+	item, _ := Load(context.Background(), tmp)
+	items, _ := item.(DirOp).Read(context.Background())
+	var ln FileItem
+	for _, v := range items {
+		if v.Name() == "ln" {
+			ln = v
+		}
+	}
+	if ln == nil || !ln.IsDir() {
+		t.Fatal("link to dir is not a dir")
+	}
+
+	// it has the op of file, but it is not read as a file
+	ctx := context.Background()
+	if _, err := Checksum(ctx, ln); err == nil {
+		t.Error("checksum of a link to dir")
+	}
+	if _, err := OpenHex(ctx, ln); err == nil {
+		t.Error("hex view of a link to dir")
+	}
+	if mt := DetectMime(ctx, ln); mt != "" {
+		t.Errorf("mime of a link to dir: %s", mt)
+	}
+	if pv, err := LoadPreview(ctx, ln, 10); err != nil || pv.Type != PreviewDir {
+		t.Errorf("link to dir is not previewed as a dir: %v", err)
+	}
+}
+
+func BenchmarkReadDirMillion(b *testing.B) {
+	dir := createMillionDir(b)
+	item, _ := Load(context.Background(), dir)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		items, err := item.(DirOp).Read(context.Background())
+		if err != nil || len(items) != millionFiles {
+			b.Fatalf("read %d items, %v", len(items), err)
+		}
+	}
+}
+
+// the time before the first entries can be shown
+func BenchmarkReadDirMillionFirstBatch(b *testing.B) {
+	dir := createMillionDir(b)
+	item, _ := Load(context.Background(), dir)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan bool, 1)
+		go ReadDir(ctx, item, time.Minute, func([]FileItem) {
+			select {
+			case first <- true:
+			default:
+			}
+		})
+		<-first
+		cancel()
+	}
+}
+
+func BenchmarkColumnMillion(b *testing.B) {
+	dir := createMillionDir(b)
+	item, _ := Load(context.Background(), dir)
+	items, err := item.(DirOp).Read(context.Background())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		co := NewLoadingColumn(item, nil)
+		co.Loaded(items, false)
+		if len(co.Files()) != millionFiles {
+			b.Fatalf("expect %d files, got %d", millionFiles, len(co.Files()))
+		}
+	}
+}
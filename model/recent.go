@@ -0,0 +1,62 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Recent the recently used strings, the most recent first, it is saved in a file line by line
+// it is safe for concurrent use
+type Recent struct {
+	path  string
+	size  int
+	items []string
+	lock  *sync.Mutex
+}
+
+// NewRecent read the recent strings from path, at most size of them are kept
+func NewRecent(path string, size int) *Recent {
+	r := &Recent{path, size, nil, new(sync.Mutex)}
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return r
+	}
+	for _, v := range strings.Split(string(bs), "\n") {
+		if v != "" && len(r.items) < size {
+			r.items = append(r.items, v)
+		}
+	}
+	return r
+}
+
+// Add str as the most recent one, it is moved to front if it is used before
+func (r *Recent) Add(str string) {
+	if str == "" || strings.Contains(str, "\n") {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	items := []string{str}
+	for _, v := range r.items {
+		if v != str && len(items) < r.size {
+			items = append(items, v)
+		}
+	}
+	r.items = items
+
+	if _, err := os.Stat(filepath.Dir(r.path)); err != nil {
+		os.MkdirAll(filepath.Dir(r.path), 0755)
+	}
+	ioutil.WriteFile(r.path, []byte(strings.Join(items, "\n")+"\n"), 0644)
+}
+
+// List the recent strings, the most recent first
+func (r *Recent) List() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.items...)
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -52,24 +53,24 @@ var (
 )
 
 var (
-	readMap = map[string]func(*sshc, string, bool) (io.Reader, error){
+	readMap = map[string]func(context.Context, *sshc, string, bool) (io.Reader, error){
 		// stat -c "%G // %U // %f // %Y // %s // %N" .* *
 		// root // root // 4168 // 1548990154 // 4096 // '.'
 		// root // root // a1ff // 1548989494 // 12 // 'systemd' -> '/etc/systemd'
-		"Linux": func(sc *sshc, path string, dir bool) (io.Reader, error) {
+		"Linux": func(ctx context.Context, sc *sshc, path string, dir bool) (io.Reader, error) {
 			if !dir {
-				return sc.exec(`stat -c "%G // %U // %f // %Y // %s // %N" "` + path + `"`)
+				return sc.execContext(ctx, `stat -c "%G // %U // %f // %Y // %s // %N" "`+path+`"`)
 			}
-			return sc.exec(`cd "` + path + `"; stat -c "%G // %U // %f // %Y // %s // %N" .* *`)
+			return sc.execContext(ctx, `cd "`+path+`"; stat -c "%G // %U // %f // %Y // %s // %N" .* *`)
 		},
 		// stat -f "%Sg // %Su // %Xp // %m // %z // '%N' -> '%Y'" .* *
 		// wheel // root // 41ed // 1532542394 // 960 // '.' -> ''
 		// wheel // root // a1ed // 1512168297 // 11 // 'var' -> 'private/var'
-		"Darwin": func(sc *sshc, path string, dir bool) (io.Reader, error) {
+		"Darwin": func(ctx context.Context, sc *sshc, path string, dir bool) (io.Reader, error) {
 			if !dir {
-				return sc.exec(`stat -f "%Sg // %Su // %Xp // %m // %z // ‘%N’ -> ‘%Y’" "` + path + `"`)
+				return sc.execContext(ctx, `stat -f "%Sg // %Su // %Xp // %m // %z // ‘%N’ -> ‘%Y’" "`+path+`"`)
 			}
-			return sc.exec(`cd "` + path + `"; stat -f "%Sg // %Su // %Xp // %m // %z // ‘%N’ -> ‘%Y’" .* *`)
+			return sc.execContext(ctx, `cd "`+path+`"; stat -f "%Sg // %Su // %Xp // %m // %z // ‘%N’ -> ‘%Y’" .* *`)
 		},
 	}
 )
@@ -91,12 +92,12 @@ func (sf *sshFileItem) Size() int64        { return sf.size }
 func (sf *sshFileItem) Sys() interface{}   { return nil }
 func (sf *sshFileItem) Link() (Link, bool) { return sf.link, sf.link != nil }
 
-func (sc *sshc) readDir(pp string) ([]FileItem, error) {
+func (sc *sshc) readDir(ctx context.Context, pp string) ([]FileItem, error) {
 	fn, ok := readMap[sc.os]
 	if !ok {
 		return nil, sc.error("target os is not supported")
 	}
-	buf, err := fn(sc, pp, true)
+	buf, err := fn(ctx, sc, pp, true)
 	if err != nil && !strings.Contains(err.Error(), "exited with status") {
 		return nil, err
 	}
@@ -125,7 +126,7 @@ func (sc *sshc) readFile(pp string) (FileItem, error) {
 	if !ok {
 		return nil, sc.error("target os is not supported")
 	}
-	buf, err := fn(sc, pp, false)
+	buf, err := fn(context.Background(), sc, pp, false)
 	if err != nil && !strings.Contains(err.Error(), "exited with status") {
 		return nil, err
 	}
@@ -242,7 +243,7 @@ type sshfile struct {
 }
 
 func (sf *sshfile) readerFromCache() (io.ReadCloser, error) {
-	cc, ok := sf.sshc.cache[sf.ipath]
+	cc, ok := sf.sshc.cached(sf.ipath)
 	if !ok {
 		return nil, errors.New("no cache")
 	}
@@ -253,7 +254,8 @@ func (sf *sshfile) readerFromCache() (io.ReadCloser, error) {
 	return os.Open(cc.path)
 }
 
-func (sf *sshfile) Reader() (io.ReadCloser, error) {
+// Reader read the file by dd, the session is closed when ctx is done
+func (sf *sshfile) Reader(ctx context.Context) (io.ReadCloser, error) {
 	rc, err := sf.readerFromCache()
 	if err == nil {
 		return rc, nil
@@ -263,7 +265,7 @@ func (sf *sshfile) Reader() (io.ReadCloser, error) {
 	if err != nil {
 		return nil, err
 	}
-	sf.sshc.cache[sf.ipath] = &sshfileCache{tmp.Name(), sf.ModTime()}
+	sf.sshc.setCache(sf.ipath, nil)
 
 	session, err := sf.sshc.conn.NewSession()
 	if err != nil {
@@ -281,7 +283,29 @@ func (sf *sshfile) Reader() (io.ReadCloser, error) {
 		return nil, err
 	}
 
-	return newReadCloser(io.TeeReader(in, tmp), session, tmp), nil
+	// a canceled reading ends with EOF as well, it is not a complete cache
+	cr := &cacheReader{io.TeeReader(in, tmp), func() {
+		if ctx.Err() == nil {
+			sf.sshc.setCache(sf.ipath, &sshfileCache{tmp.Name(), sf.ModTime()})
+		}
+	}}
+	return newReadCloser(cr, closeOnDone(ctx, session), session, tmp), nil
+}
+
+// cacheReader only a fully read file can be used as cache,
+// a preview may stop reading at any point
+type cacheReader struct {
+	io.Reader
+	done func()
+}
+
+func (cr *cacheReader) Read(p []byte) (int, error) {
+	n, err := cr.Reader.Read(p)
+	if err == io.EOF && cr.done != nil {
+		cr.done()
+		cr.done = nil
+	}
+	return n, err
 }
 
 func (sf *sshfile) Writer(int) (io.WriteCloser, error) {
@@ -301,7 +325,33 @@ func (sf *sshfile) Writer(int) (io.WriteCloser, error) {
 		return nil, err
 	}
 
-	return newWriteCloser(out, out, session), nil
+	// dd is waited, so that the file is complete when the writer is closed
+	// the session is closed by then, closing it again is not an error
+	closeSession := closerFunc(func() error {
+		if err := session.Close(); err != io.EOF {
+			return err
+		}
+		return nil
+	})
+	return newWriteCloser(out, out, closerFunc(session.Wait), closeSession), nil
+}
+
+// Checksum the sha256 computed on the remote host, the file is not transferred
+func (sf *sshfile) Checksum(ctx context.Context) (string, error) {
+	cmd := `sha256sum "%s"`
+	if sf.sshc.os == "Darwin" {
+		cmd = `shasum -a 256 "%s"`
+	}
+	buf, err := sf.sshc.execContext(ctx, fmt.Sprintf(cmd, sf.ipath))
+	if err != nil {
+		return "", err
+	}
+
+	ts := strings.Fields(buf.String())
+	if len(ts) == 0 {
+		return "", sf.sshc.error("no checksum of " + sf.ipath)
+	}
+	return ts[0], nil
 }
 
 func (sf *sshfile) View() error {
@@ -330,8 +380,8 @@ type sshdir struct {
 	*sshItem
 }
 
-func (sd *sshdir) Read() ([]FileItem, error) {
-	return sd.sshc.readDir(sd.ipath)
+func (sd *sshdir) Read(ctx context.Context) ([]FileItem, error) {
+	return sd.sshc.readDir(ctx, sd.ipath)
 }
 
 func (sd *sshdir) write(item FileItem, root string) ([]Task, error) {
@@ -343,11 +393,12 @@ func (sd *sshdir) write(item FileItem, root string) ([]Task, error) {
 		return sd.writeSameHost(sdd, root)
 	}
 
-	return []Task{NewTask(item.Name(), func(progress chan<- int, quit <-chan bool, eh chan<- error) {
+	return []Task{NewTransferTask(item.Name(), item.Size(), func(ctx context.Context, meter *Meter, progress chan<- int, eh chan<- error) {
 		defer close(progress)
 		defer close(eh)
 
-		r, err := item.(FileOp).Reader()
+		ctx = throughLoaders(ctx, item.Path(), sd.Path())
+		r, err := item.(FileOp).Reader(ctx)
 		if err != nil {
 			eh <- err
 			return
@@ -389,70 +440,45 @@ func (sd *sshdir) write(item FileItem, root string) ([]Task, error) {
 			eh <- err
 			return
 		}
-		defer w.Close()
-
-		buf := make([]byte, 4096)
-		pg := 0
-		si := float64(item.Size())
-
-		var count int64
-		var quited = false
-
-		go func() {
-			<-quit
-			quited = true
-		}()
 
-		for !quited {
-			n, err := r.Read(buf)
-			if n > 0 {
-				_, err2 := w.Write(buf[:n])
-				if err2 != nil {
-					eh <- err2
-					return
-				}
-
-				count += int64(n)
-				pp := int(float64(count) / si * 100)
-				if pp > pg {
-					pg = pp
-					progress <- pg
-				}
-			}
-			if err == io.EOF {
-				break
-			}
-
-			if err != nil {
-				eh <- err
-				return
-			}
+		// the remote write fails on close, when dd exits
+		err = copyData(ctx, w, r, meter, progress)
+		if cerr := w.Close(); err == nil {
+			err = cerr
 		}
-	})}, nil
-}
-
-func (sd *sshdir) findPid(cmd, cmdstring string) (int, error) {
-	cmds := strings.Replace(cmdstring, `"`, "", -1)
-	buf, err := sd.sshc.execf(`ps -eo pid,comm,args | grep "%s"`, cmds)
-	if err != nil {
-		return 0, nil
-	}
-
-	re := regexp.MustCompile(`^(\d+)\s+([^\s]+)`)
-	for _, v := range strings.Split(buf.String(), "\n") {
-		ss := re.FindStringSubmatch(v)
-		if len(ss) != 3 {
-			continue
+		if err != nil {
+			reportError(ctx, eh, err)
+			return
 		}
-		if ss[2] == cmd {
-			return strconv.Atoi(ss[1])
+		if err = checkCopy(ctx, item, func() (FileItem, error) { return nf, nil }); err != nil {
+			eh <- err
 		}
-	}
-	return 0, errors.New("not found")
+	})}, nil
 }
 
+// sameHostCopy copy a file by dd on the remote host, dd prints its progress when signaled
+// the data does not go through the link, so the rate limits do not apply
+// dd is stopped and continued by the commands in stdin, closing the stdin kills dd,
+// so that a paused or canceled copy is handled without looking up its pid
+const sameHostCopy = `dd if="%s" of="%s" & pid=$!
+exec 3<&0
+(while read cmd <&3; do
+  case $cmd in
+  stop) kill -STOP $pid;;
+  cont) kill -CONT $pid;;
+  esac
+done
+kill $pid; kill -CONT $pid) >/dev/null 2>&1 &
+watcher=$!
+(while sleep 2; do kill -%s $pid || exit 0; done) >/dev/null 2>&1 &
+ticker=$!
+wait $pid
+code=$?
+kill $watcher $ticker >/dev/null 2>&1
+exit $code`
+
 func (sd *sshdir) writeSameHost(item *sshfile, root string) ([]Task, error) {
-	return []Task{NewTask(item.Name(), func(progress chan<- int, quit <-chan bool, eh chan<- error) {
+	return []Task{NewTransferTask(item.Name(), item.Size(), func(ctx context.Context, meter *Meter, progress chan<- int, eh chan<- error) {
 		defer close(progress)
 		defer close(eh)
 
@@ -480,18 +506,56 @@ func (sd *sshdir) writeSameHost(item *sshfile, root string) ([]Task, error) {
 		}
 		defer se.Close()
 
+		in, err := se.StdinPipe()
+		if err != nil {
+			eh <- err
+			return
+		}
 		out, err := se.StderrPipe()
 		if err != nil {
 			eh <- err
 			return
 		}
 
-		ended := false
+		signal := "INFO"
+		if sd.sshc.os == "Linux" {
+			signal = "USR1"
+		}
+		err = se.Start(fmt.Sprintf(sameHostCopy, item.ipath, filepath.Join(sd.ipath, rel), signal))
+		if err != nil {
+			eh <- err
+			return
+		}
+		defer closeOnDone(ctx, in).Close()
+
+		ended := make(chan bool)
+		defer close(ended)
+		if gate := pauserOf(ctx); gate != nil {
+			go func() {
+				for {
+					cmd := "cont\n"
+					paused, changed := gate.state()
+					if paused {
+						cmd = "stop\n"
+					}
+					io.WriteString(in, cmd)
+
+					select {
+					case <-changed:
+					case <-ended:
+						return
+					}
+				}
+			}()
+		}
+
+		// counted is only touched by the scanner until scanned is closed
+		var counted int64
+		scanned := make(chan bool)
 		go func() {
+			defer close(scanned)
 			sc := bufio.NewScanner(out)
 			re := regexp.MustCompile(`^\s*(\d+)\s+bytes.*(?:copied|transferred)`)
-			size := float64(item.Size())
-			pg := 0
 			for sc.Scan() {
 				// linux: kill -USR1 ##### 2109121536 bytes (2.1 GB) copied, 23.7728 s, 88.7 MB/s
 				// drawin: kill -INFO ##### 707673088 bytes transferred in 10.532818 secs (67187442 bytes/sec)
@@ -505,56 +569,32 @@ func (sd *sshdir) writeSameHost(item *sshfile, root string) ([]Task, error) {
 					continue
 				}
 
-				pp := int(float64(copied) / size * 100)
-				if !ended && pp > pg {
-					pg = pp
-					progress <- pp
+				if copied > counted {
+					meter.Add(copied - counted)
+					counted = copied
+					progress <- meter.Percent()
 				}
 			}
 		}()
 
-		cmds := fmt.Sprintf(`dd if="%s" of="%s"`, item.ipath, filepath.Join(sd.ipath, rel))
-		err = se.Start(cmds)
-		if err != nil {
-			eh <- err
-			return
-		}
-
-		pid, err := sd.findPid("dd", cmds)
+		// the stderr is closed after the session ended, no progress is sent after that
+		err = se.Wait()
+		<-scanned
 		if err != nil {
-			eh <- err
+			if ctx.Err() == nil {
+				eh <- err
+			}
 			return
 		}
-
-		endch := make(chan bool)
-		go func() {
-			for {
-				select {
-				case <-quit:
-					sd.sshc.execf("kill -TERM %d", pid)
-				case <-time.After(2 * time.Second):
-					if sd.sshc.os == "Linux" {
-						sd.sshc.execf("kill -USR1 %d", pid)
-					} else {
-						sd.sshc.execf("kill -INFO %d", pid)
-					}
-				case <-endch:
-					return
-				}
-			}
-		}()
-
-		err = se.Wait()
-		if err != nil {
+		meter.Add(item.Size() - counted)
+		if err = checkCopy(ctx, item, func() (FileItem, error) { return sd.To(rel) }); err != nil {
 			eh <- err
 		}
-		endch <- true
-		ended = true
 	})}, nil
 }
 
 func (sd *sshdir) writeDir(item FileItem, root string) ([]Task, error) {
-	its, err := item.(DirOp).Read()
+	its, err := item.(DirOp).Read(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -2,12 +2,14 @@ package model
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -21,6 +23,7 @@ var (
 
 var (
 	sshCache = make(map[string]*sshc)
+	sshLock  = new(sync.Mutex)
 )
 
 type sshconfig struct {
@@ -38,7 +41,10 @@ type sshc struct {
 	root   FileItem
 	loader Loader
 	tmpDir string
-	cache  map[string]*sshfileCache
+
+	// the files read through, the readers of tasks and previews use it concurrently
+	cache     map[string]*sshfileCache
+	cacheLock *sync.Mutex
 }
 
 type sshfileCache struct {
@@ -46,6 +52,24 @@ type sshfileCache struct {
 	modTime time.Time
 }
 
+func (ss *sshc) cached(path string) (*sshfileCache, bool) {
+	ss.cacheLock.Lock()
+	defer ss.cacheLock.Unlock()
+	cc, ok := ss.cache[path]
+	return cc, ok
+}
+
+// setCache set the cache of path, nil to drop it
+func (ss *sshc) setCache(path string, cc *sshfileCache) {
+	ss.cacheLock.Lock()
+	defer ss.cacheLock.Unlock()
+	if cc == nil {
+		delete(ss.cache, path)
+		return
+	}
+	ss.cache[path] = cc
+}
+
 func (ss *sshc) error(msg string) error {
 	return fmt.Errorf("%s: %s", ss.loader.Name(), msg)
 }
@@ -88,15 +112,24 @@ func (ss *sshc) execf(format string, args ...interface{}) (*bytes.Buffer, error)
 }
 
 func (ss *sshc) exec(cmd string) (*bytes.Buffer, error) {
+	return ss.execContext(context.Background(), cmd)
+}
+
+// execContext run cmd, the session is closed when ctx is done
+func (ss *sshc) execContext(ctx context.Context, cmd string) (*bytes.Buffer, error) {
 	session, err := ss.conn.NewSession()
 	if err != nil {
 		return nil, err
 	}
 	defer session.Close()
+	defer closeOnDone(ctx, session).Close()
 
 	var out bytes.Buffer
 	session.Stdout = &out
 	err = session.Run(cmd)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	return &out, err
 }
 
@@ -105,32 +138,35 @@ type sshLoader struct{}
 func (*sshLoader) Name() string               { return "ssh" }
 func (*sshLoader) Seperator() string          { return "/" }
 func (*sshLoader) Support(item FileItem) bool { return strings.HasSuffix(item.Name(), ".ssh.fff") }
-func (sl *sshLoader) Create(origin FileItem) (FileItem, error) {
-	sc, err := sl.loadConfig(origin)
+func (sl *sshLoader) Create(ctx context.Context, origin FileItem) (FileItem, error) {
+	sc, err := sl.loadConfig(ctx, origin)
 	if err != nil {
 		return nil, err
 	}
 	key := fmt.Sprintf("%s@%s", sc.user, sc.host)
+	sshLock.Lock()
 	ssc, ok := sshCache[key]
+	sshLock.Unlock()
 	if ok {
-		_, err = ssc.exec("uname")
+		_, err = ssc.execContext(ctx, "uname")
 		if err != nil {
 			ok = false
 		}
 	}
 	if !ok {
-		conn, err := sl.login(sc)
+		conn, err := sl.login(ctx, sc)
 		if err != nil {
 			return nil, err
 		}
 
-		td, err := ioutil.TempDir("", "fff")
+		td, err := tempDir("ssh-")
 		if err != nil {
 			return nil, err
 		}
-		ssc = &sshc{sc, conn, "", origin, nil, sl, td, make(map[string]*sshfileCache)}
-		buf, err := ssc.exec("uname")
+		ssc = &sshc{sc, conn, "", origin, nil, sl, td, make(map[string]*sshfileCache), new(sync.Mutex)}
+		buf, err := ssc.execContext(ctx, "uname")
 		if err != nil {
+			conn.Close()
 			return nil, err
 		}
 		ssc.os = strings.Trim(buf.String(), " \n")
@@ -138,13 +174,37 @@ func (sl *sshLoader) Create(origin FileItem) (FileItem, error) {
 		sfi := &sshFileItem{"/", "root", "root", time.Now(), 0, 0755, true, nil}
 		root := &sshroot{&sshdir{&sshItem{ssc, "/", sfi}}}
 		ssc.root = root
+		sshLock.Lock()
 		sshCache[key] = ssc
+		sshLock.Unlock()
 	}
 
 	return ssc.root, nil
 }
 
-func (*sshLoader) login(sc *sshconfig) (*ssh.Client, error) {
+// dial connect to host, the connecting and handshake stop when ctx is done
+func dial(ctx context.Context, host string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	d := &net.Dialer{Timeout: cfg.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := closeOnDone(ctx, conn)
+	c, chans, reqs, err := ssh.NewClientConn(conn, host, cfg)
+	stop.Close()
+	if ctx.Err() != nil {
+		conn.Close()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+func (*sshLoader) login(ctx context.Context, sc *sshconfig) (*ssh.Client, error) {
 	cfg := &ssh.ClientConfig{
 		User:    sc.user,
 		Timeout: sc.timeout,
@@ -156,7 +216,7 @@ func (*sshLoader) login(sc *sshconfig) (*ssh.Client, error) {
 
 	if sc.password != "" {
 		cfg.Auth = []ssh.AuthMethod{ssh.Password(sc.password)}
-		conn, err := ssh.Dial("tcp", host, cfg)
+		conn, err := dial(ctx, host, cfg)
 		if err != nil {
 			return nil, err
 		}
@@ -175,22 +235,25 @@ func (*sshLoader) login(sc *sshconfig) (*ssh.Client, error) {
 		}
 
 		cfg.Auth = []ssh.AuthMethod{ssh.PublicKeys(key)}
-		return ssh.Dial("tcp", host, cfg)
+		return dial(ctx, host, cfg)
 	}
 
 	if ag, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK")); err == nil {
 		cfg.Auth = []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(ag).Signers)}
-		conn, err := ssh.Dial("tcp", host, cfg)
+		conn, err := dial(ctx, host, cfg)
+		ag.Close()
 		if err == nil {
 			return conn, nil
 		}
 	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 
-	RequestCh <- &Request{fmt.Sprintf("Enter password for %s@%s", sc.user, sc.host), true}
-	pw := <-ResponseCh
+	pw := Ask(fmt.Sprintf("Enter password for %s@%s", sc.user, sc.host), true)
 	if pw != "" {
 		cfg.Auth = []ssh.AuthMethod{ssh.Password(pw)}
-		conn, err := ssh.Dial("tcp", host, cfg)
+		conn, err := dial(ctx, host, cfg)
 		if err != nil {
 			return nil, err
 		}
@@ -199,8 +262,8 @@ func (*sshLoader) login(sc *sshconfig) (*ssh.Client, error) {
 	return nil, errors.New("login fail")
 }
 
-func (*sshLoader) loadConfig(file FileItem) (*sshconfig, error) {
-	re, err := file.(FileOp).Reader()
+func (*sshLoader) loadConfig(ctx context.Context, file FileItem) (*sshconfig, error) {
+	re, err := file.(FileOp).Reader(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package model
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"io"
 	"os"
 	"path"
@@ -17,8 +18,8 @@ var (
 	_ = DirOp(new(tarDir))
 )
 
-func openTar(a archive) (io.Closer, *tar.Reader, error) {
-	in, err := a.origin().(FileOp).Reader()
+func openTar(ctx context.Context, a archive) (io.Closer, *tar.Reader, error) {
+	in, err := a.origin().(FileOp).Reader(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -41,7 +42,7 @@ func writeTar(a archive) (io.Closer, *tar.Writer, error) {
 		return nil, nil, a.error("write to compressed tar is not supported")
 	}
 
-	in, err := a.origin().(FileOp).Reader()
+	in, err := a.origin().(FileOp).Reader(context.Background())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -104,8 +105,8 @@ func newTarFile(ai archiveItem) *tarFile {
 	return &tarFile{&archiveFileOp{&archiveOp{ai}}}
 }
 
-func (tf *tarFile) Reader() (io.ReadCloser, error) {
-	c, re, err := openTar(tf.archive())
+func (tf *tarFile) Reader(ctx context.Context) (io.ReadCloser, error) {
+	c, re, err := openTar(ctx, tf.archive())
 	if err != nil {
 		return nil, err
 	}
@@ -151,11 +152,12 @@ func (td *tarDir) write(w *tar.Writer, root string, item FileItem) ([]Task, erro
 		return td.writeDir(w, root, item)
 	}
 
-	return []Task{NewTask(item.Name(), func(progress chan<- int, quit <-chan bool, eh chan<- error) {
+	return []Task{NewTransferTask(item.Name(), item.Size(), func(ctx context.Context, meter *Meter, progress chan<- int, eh chan<- error) {
 		defer close(progress)
 		defer close(eh)
 
-		r, err := item.(FileOp).Reader()
+		ctx = throughLoaders(ctx, item.Path(), td.Path())
+		r, err := item.(FileOp).Reader(ctx)
 		if err != nil {
 			eh <- err
 			return
@@ -175,46 +177,16 @@ func (td *tarDir) write(w *tar.Writer, root string, item FileItem) ([]Task, erro
 			return
 		}
 
-		quited := false
-		go func() {
-			<-quit
-			quited = true
-		}()
-
-		buf := make([]byte, 4096)
-		pg := 0
-		si := float64(item.Size())
-		var count int64
-
-		for !quited {
-			n, err := r.Read(buf)
-			if n > 0 {
-				_, err2 := w.Write(buf[:n])
-				if err2 != nil {
-					eh <- err2
-					return
-				}
-
-				count += int64(n)
-				pp := int(float64(count) / si * 100)
-				if pp > pg {
-					pg = pp
-					progress <- pg
-				}
-			}
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				eh <- err
-				break
-			}
+		// the archive can not be read before it is closed, so the copy is not verified
+		err = copyData(ctx, w, r, meter, progress)
+		if err != nil {
+			reportError(ctx, eh, err)
 		}
 	})}, nil
 }
 
 func (td *tarDir) writeDir(w *tar.Writer, root string, item FileItem) ([]Task, error) {
-	its, err := item.(DirOp).Read()
+	its, err := item.(DirOp).Read(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -245,6 +217,8 @@ func (td *tarDir) Write(items []FileItem) (Task, error) {
 		ts = append(ts, s...)
 	}
 
+	// the archive is closed with the batch, nothing can be written into it again
+	noRetry(ts)
 	bt := NewBatchTask("Copy", ts)
 	bt.Attach(NewListener(nil, func() {
 		w.Close()
@@ -262,9 +236,9 @@ func (*tarLoader) Support(item FileItem) bool {
 	return !item.IsDir() && strings.HasSuffix(item.Name(), ".tar")
 }
 
-func newTarArchive(ld Loader, wrapper *tarWrapper, item FileItem) (archive, error) {
+func newTarArchive(ctx context.Context, ld Loader, wrapper *tarWrapper, item FileItem) (archive, error) {
 	ta := &defaultArchive{ld, item, nil, nil, wrapper}
-	file, reader, err := openTar(ta)
+	file, reader, err := openTar(ctx, ta)
 	if err != nil {
 		return nil, err
 	}
@@ -274,6 +248,10 @@ func newTarArchive(ld Loader, wrapper *tarWrapper, item FileItem) (archive, erro
 
 	items := make([]archiveItem, 0)
 	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
 		h, err := reader.Next()
 		if err == io.EOF {
 			break
@@ -297,8 +275,8 @@ func newTarArchive(ld Loader, wrapper *tarWrapper, item FileItem) (archive, erro
 	return ta, nil
 }
 
-func (tl *tarLoader) Create(item FileItem) (FileItem, error) {
-	ta, err := newTarArchive(tl, new(tarWrapper), item)
+func (tl *tarLoader) Create(ctx context.Context, item FileItem) (FileItem, error) {
+	ta, err := newTarArchive(ctx, tl, new(tarWrapper), item)
 	if err != nil {
 		return nil, err
 	}
@@ -317,8 +295,8 @@ func (*tgzLoader) Support(item FileItem) bool {
 	return strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".tar.gz")
 }
 
-func (tl *tgzLoader) Create(item FileItem) (FileItem, error) {
-	ta, err := newTarArchive(tl, &tarWrapper{func(reader io.Reader) (io.ReadCloser, error) {
+func (tl *tgzLoader) Create(ctx context.Context, item FileItem) (FileItem, error) {
+	ta, err := newTarArchive(ctx, tl, &tarWrapper{func(reader io.Reader) (io.ReadCloser, error) {
 		return gzip.NewReader(reader)
 	}, func(writer io.Writer) (io.WriteCloser, error) {
 		return gzip.NewWriter(writer), nil
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"context"
+	"sync"
+)
+
 var (
 	_ = Progresser(new(DefaultProgresser))
 	_ = Task(new(DefaultTask))
@@ -47,11 +52,16 @@ type Progresser interface {
 	End()
 }
 
-// DefaultProgresser a progress notifier
+// DefaultProgresser a progress notifier, it is safe for concurrent use
 type DefaultProgresser struct {
 	count     int
 	progress  int
 	listeners []ProgressListener
+	lock      *sync.Mutex
+}
+
+func newProgresser(count int) *DefaultProgresser {
+	return &DefaultProgresser{count, 0, nil, new(sync.Mutex)}
 }
 
 // Count progress count
@@ -61,13 +71,19 @@ func (dp *DefaultProgresser) Count() int {
 
 // Current current progress
 func (dp *DefaultProgresser) Current() int {
+	dp.lock.Lock()
+	defer dp.lock.Unlock()
 	return dp.progress
 }
 
 // Progress set the progress
 func (dp *DefaultProgresser) Progress(c int) {
+	dp.lock.Lock()
 	dp.progress = c
-	for _, v := range dp.listeners {
+	ls := dp.listeners
+	dp.lock.Unlock()
+
+	for _, v := range ls {
 		v.Notify(c)
 	}
 }
@@ -82,6 +98,8 @@ func (a *actionRemover) Remove() {
 
 // Attach attach notifier
 func (dp *DefaultProgresser) Attach(listener ProgressListener) Remover {
+	dp.lock.Lock()
+	defer dp.lock.Unlock()
 	dp.listeners = append(dp.listeners, listener)
 	return &actionRemover{func() {
 		dp.detach(listener)
@@ -89,28 +107,49 @@ func (dp *DefaultProgresser) Attach(listener ProgressListener) Remover {
 }
 
 func (dp *DefaultProgresser) detach(listener ProgressListener) {
+	dp.lock.Lock()
+	defer dp.lock.Unlock()
+
 	ls := make([]ProgressListener, 0)
 	for _, v := range dp.listeners {
 		if v != listener {
-			continue
+			ls = append(ls, v)
 		}
-		ls = append(ls, v)
 	}
 	dp.listeners = ls
 }
 
 // End close all listeners
 func (dp *DefaultProgresser) End() {
-	for _, v := range dp.listeners {
+	dp.lock.Lock()
+	ls := dp.listeners
+	dp.listeners = nil
+	dp.lock.Unlock()
+
+	for _, v := range ls {
 		v.End()
 	}
-	dp.listeners = nil
 }
 
 // Task a task
 type Task interface {
 	Name() string
-	Start(<-chan bool, chan<- error)
+	Start(context.Context, chan<- error)
+
+	// Meter the bytes transferred, nil if the task does not transfer bytes
+	Meter() *Meter
+
+	// Pause the task, the copy loops stop at the next chunk until it is resumed
+	Pause()
+	Resume()
+	IsPaused() bool
+
+	// Retry create a new task doing the same thing, nil if the task can not be retried
+	Retry() Task
+
+	// Throttle limit the bytes copied per second, 0 for no limit, the tasks in a batch share the limit
+	Throttle(rate int64)
+	RateLimit() int64
 	Progresser
 }
 
@@ -123,13 +162,35 @@ type BatchTask interface {
 // DefaultTask default task
 type DefaultTask struct {
 	name   string
-	action func(chan<- int, <-chan bool, chan<- error)
+	action func(context.Context, chan<- int, chan<- error)
+	meter  *Meter
+	gate   *pauser
+	limit  *limiter
+	retry  func() Task
 	*DefaultProgresser
 }
 
-// NewTask create task
-func NewTask(name string, action func(chan<- int, <-chan bool, chan<- error)) Task {
-	return &DefaultTask{name, action, &DefaultProgresser{100, 0, nil}}
+// NewTask create task, action should return soon after ctx is done and close both chans
+func NewTask(name string, action func(context.Context, chan<- int, chan<- error)) Task {
+	return &DefaultTask{name, action, nil, newPauser(), newLimiter(0), func() Task { return NewTask(name, action) }, newProgresser(100)}
+}
+
+// reportError send err of an action to eh, unless ctx is done
+// the error is caused by the cancel then, and the task is recorded as canceled as a whole
+func reportError(ctx context.Context, eh chan<- error, err error) {
+	if ctx.Err() == nil {
+		eh <- err
+	}
+}
+
+// NewTransferTask create task transferring size bytes, action adds the transferred bytes to the meter
+// the bytes not transferred when action returned are dropped from the meter
+func NewTransferTask(name string, size int64, action func(context.Context, *Meter, chan<- int, chan<- error)) Task {
+	meter := newMeter(size)
+	return &DefaultTask{name, func(ctx context.Context, progress chan<- int, err chan<- error) {
+		defer meter.Drop()
+		action(ctx, meter, progress, err)
+	}, meter, newPauser(), newLimiter(0), func() Task { return NewTransferTask(name, size, action) }, newProgresser(100)}
 }
 
 // Name return task name
@@ -137,14 +198,67 @@ func (dt *DefaultTask) Name() string {
 	return dt.name
 }
 
-// Start start the task
-func (dt *DefaultTask) Start(quit <-chan bool, err chan<- error) {
+// Meter the bytes transferred
+func (dt *DefaultTask) Meter() *Meter {
+	return dt.meter
+}
+
+// Pause the task
+func (dt *DefaultTask) Pause() {
+	dt.gate.pause()
+}
+
+// Resume the paused task
+func (dt *DefaultTask) Resume() {
+	dt.gate.resume()
+}
+
+// Retry create the same task again, nil if it can not be retried
+func (dt *DefaultTask) Retry() Task {
+	if dt.retry == nil {
+		return nil
+	}
+	return dt.retry()
+}
+
+// noRetry mark the tasks can not be retried, such as the tasks sharing a writer closed when they end
+func noRetry(tasks []Task) {
+	for _, v := range tasks {
+		if dt, ok := v.(*DefaultTask); ok {
+			dt.retry = nil
+		}
+	}
+}
+
+// Throttle limit the bytes copied per second
+func (dt *DefaultTask) Throttle(rate int64) {
+	dt.limit.setRate(rate)
+}
+
+// RateLimit the bytes can be copied per second, 0 for no limit
+func (dt *DefaultTask) RateLimit() int64 {
+	return dt.limit.getRate()
+}
+
+// IsPaused if the task is paused
+func (dt *DefaultTask) IsPaused() bool {
+	paused, _ := dt.gate.state()
+	return paused
+}
+
+// Start start the task, it returns when the action is finished or ctx is done
+// the task in a batch is paused with the batch
+func (dt *DefaultTask) Start(ctx context.Context, err chan<- error) {
 	defer dt.End()
 
+	if pauserOf(ctx) == nil {
+		ctx = context.WithValue(ctx, pauserKey{}, dt.gate)
+	}
+	if limiterOf(ctx) == nil {
+		ctx = context.WithValue(ctx, limiterKey{}, dt.limit)
+	}
 	prog := make(chan int)
-	qt := make(chan bool)
-
-	go dt.action(prog, qt, err)
+	go dt.action(ctx, prog, err)
 	for {
 		select {
 		case p, ok := <-prog:
@@ -152,9 +266,8 @@ func (dt *DefaultTask) Start(quit <-chan bool, err chan<- error) {
 				return
 			}
 			dt.Progress(p)
-		case <-quit:
-			qt <- true
-			close(qt)
+		case <-ctx.Done():
+			go drainInt(prog)
 			return
 		}
 	}
@@ -166,33 +279,48 @@ type DefaultBatchTask struct {
 	*DefaultTask
 }
 
-// NewBatchTask create batch task
+// NewBatchTask create batch task, the bytes of the transfers in tasks are counted together
 func NewBatchTask(name string, tasks []Task) BatchTask {
-	return &DefaultBatchTask{tasks, &DefaultTask{name, nil, &DefaultProgresser{len(tasks), 0, nil}}}
+	var meter *Meter
+	for _, v := range tasks {
+		if m := v.Meter(); m != nil {
+			if meter == nil {
+				meter = newMeter(0)
+			}
+			m.attach(meter)
+		}
+	}
+	return &DefaultBatchTask{tasks, &DefaultTask{name, nil, meter, newPauser(), newLimiter(0), nil, newProgresser(len(tasks))}}
 }
 
 // CurrentTask the current task
 func (bt *DefaultBatchTask) CurrentTask() Task {
-	return bt.tasks[bt.progress]
+	return bt.tasks[bt.Current()]
 }
 
 // Start task one by one
-func (bt *DefaultBatchTask) Start(quit <-chan bool, err chan<- error) {
+func (bt *DefaultBatchTask) Start(ctx context.Context, err chan<- error) {
 	defer bt.End()
 	defer close(err)
 
+	ctx = context.WithValue(ctx, pauserKey{}, bt.gate)
+	ctx = context.WithValue(ctx, limiterKey{}, bt.limit)
 	for i, t := range bt.tasks {
-		qt := make(chan bool)
+		i, t := i, t
+		if bt.gate.wait(ctx) != nil {
+			return
+		}
+
 		err1 := make(chan error)
 		bt.Progress(i)
-		finished := make(chan bool)
+		finished := make(chan bool, 1)
 
 		t.Attach(NewListener(func(pp int) {
 			bt.Progress(i)
 		}, func() {
 			finished <- true
 		}))
-		go t.Start(qt, err1)
+		go t.Start(ctx, err1)
 	progress:
 		for {
 			select {
@@ -200,10 +328,12 @@ func (bt *DefaultBatchTask) Start(quit <-chan bool, err chan<- error) {
 				if !ok {
 					break progress
 				}
+				if _, ok := e.(*TaskError); !ok {
+					e = &TaskError{t, e}
+				}
 				err <- e
-			case <-quit:
-				qt <- true
-				close(qt)
+			case <-ctx.Done():
+				go drainError(err1)
 				return
 			case <-finished:
 				break progress
@@ -246,69 +376,138 @@ func (t *tlistener) Progress(tt Task) {
 	}
 }
 
-// TaskManager manage tasks
+// TaskManager manage tasks, the finished tasks are kept in history, it is safe for concurrent use
 type TaskManager struct {
-	Tasks     []Task
-	quits     map[Task]chan bool
+	tasks     []Task
+	cancels   map[Task]context.CancelFunc
+	records   map[Task]*TaskRecord
+	history   []*TaskRecord
 	listeners []TaskListener
+	lock      *sync.Mutex
 }
 
 // NewTaskManager create task manager
 func NewTaskManager() *TaskManager {
-	return &TaskManager{nil, make(map[Task]chan bool), nil}
+	return &TaskManager{nil, make(map[Task]context.CancelFunc), make(map[Task]*TaskRecord), nil, nil, new(sync.Mutex)}
+}
+
+// Tasks the running tasks
+func (tm *TaskManager) Tasks() []Task {
+	tm.lock.Lock()
+	defer tm.lock.Unlock()
+	return append([]Task(nil), tm.tasks...)
+}
+
+// History the finished tasks, the latest first
+func (tm *TaskManager) History() []*TaskRecord {
+	tm.lock.Lock()
+	defer tm.lock.Unlock()
+	return append([]*TaskRecord(nil), tm.history...)
+}
+
+func (tm *TaskManager) taskListeners() []TaskListener {
+	tm.lock.Lock()
+	defer tm.lock.Unlock()
+	return tm.listeners
 }
 
 // Submit a task to execute
 func (tm *TaskManager) Submit(task Task) <-chan string {
-	tm.Tasks = append(tm.Tasks, task)
-	quit := make(chan bool)
+	ctx, cancel := context.WithCancel(context.Background())
 	err := make(chan error)
 	message := make(chan string)
-	for _, v := range tm.listeners {
+
+	record := newTaskRecord(task)
+	tm.lock.Lock()
+	tm.tasks = append(tm.tasks, task)
+	tm.cancels[task] = cancel
+	tm.records[task] = record
+	tm.lock.Unlock()
+
+	for _, v := range tm.taskListeners() {
 		v.Submitted(task)
 	}
 	task.Attach(NewListener(func(p int) {
-		for _, v := range tm.listeners {
+		for _, v := range tm.taskListeners() {
 			v.Progress(task)
 		}
 	}, func() {
+		tm.lock.Lock()
 		ts := make([]Task, 0)
-		for _, v := range tm.Tasks {
+		for _, v := range tm.tasks {
 			if v != task {
 				ts = append(ts, v)
 			}
 		}
-		tm.Tasks = ts
-		delete(tm.quits, task)
-		for _, v := range tm.listeners {
+		tm.tasks = ts
+		delete(tm.cancels, task)
+		delete(tm.records, task)
+		record.finish()
+		tm.history = append([]*TaskRecord{record}, tm.history...)
+		if len(tm.history) > historySize {
+			tm.history = tm.history[:historySize]
+		}
+		tm.lock.Unlock()
+		cancel()
+
+		for _, v := range tm.taskListeners() {
 			v.Finished(task)
 		}
 	}))
 
-	go task.Start(quit, err)
+	go task.Start(ctx, err)
 	go func() {
 		for v := range err {
+			record.addError(v)
 			message <- v.Error()
 		}
 		close(message)
 	}()
 
-	tm.quits[task] = quit
 	return message
 }
 
+// Pause task, the listeners are notified as a progress
+func (tm *TaskManager) Pause(task Task) {
+	task.Pause()
+	for _, v := range tm.taskListeners() {
+		v.Progress(task)
+	}
+}
+
+// Resume the paused task
+func (tm *TaskManager) Resume(task Task) {
+	task.Resume()
+	for _, v := range tm.taskListeners() {
+		v.Progress(task)
+	}
+}
+
 // Cancel task
 func (tm *TaskManager) Cancel(task Task) {
-	if quit, ok := tm.quits[task]; ok {
-		quit <- true
-		close(quit)
+	tm.lock.Lock()
+	cancel, ok := tm.cancels[task]
+	delete(tm.cancels, task)
+	if record, has := tm.records[task]; has {
+		record.cancel()
+	}
+	tm.lock.Unlock()
+
+	if ok {
+		cancel()
 	}
 }
 
 // Attach listener
 func (tm *TaskManager) Attach(tl TaskListener) Remover {
+	tm.lock.Lock()
+	defer tm.lock.Unlock()
+
 	tm.listeners = append(tm.listeners, tl)
 	return &actionRemover{func() {
+		tm.lock.Lock()
+		defer tm.lock.Unlock()
+
 		ls := make([]TaskListener, 0)
 		for _, v := range tm.listeners {
 			if v != tl {
@@ -318,3 +517,14 @@ func (tm *TaskManager) Attach(tl TaskListener) Remover {
 		tm.listeners = ls
 	}}
 }
+
+// drainInt read ch until it is closed, so that the sender is not blocked after nobody cares
+func drainInt(ch <-chan int) {
+	for range ch {
+	}
+}
+
+func drainError(ch <-chan error) {
+	for range ch {
+	}
+}
@@ -0,0 +1,230 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func stepTask(name string, steps int) Task {
+	return NewTask(name, func(ctx context.Context, progress chan<- int, err chan<- error) {
+		defer close(err)
+		defer close(progress)
+		for i := 0; i <= steps; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case progress <- i:
+			}
+		}
+	})
+}
+
+func TestTaskManagerConcurrent(t *testing.T) {
+	tm := NewTaskManager()
+	finished := make(chan Task, 100)
+	tm.Attach(NewTaskListener(nil, func(task Task) {
+		finished <- task
+	}, func(task Task) {
+		task.Current()
+		tm.Tasks()
+	}))
+
+	count := 40
+	wg := new(sync.WaitGroup)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var task Task
+			if i%4 == 0 {
+				task = NewBatchTask("batch", []Task{stepTask("a", 50), stepTask("b", 50)})
+			} else {
+				task = stepTask("task", 100)
+			}
+			tm.Submit(task)
+			if i%3 == 0 {
+				tm.Cancel(task)
+				tm.Cancel(task)
+			}
+		}(i)
+	}
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			for _, v := range tm.Tasks() {
+				v.Current()
+			}
+		}
+	}()
+	wg.Wait()
+
+	for i := 0; i < count; i++ {
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d tasks finished", i, count)
+		}
+	}
+	if len(tm.Tasks()) != 0 {
+		t.Errorf("finished tasks are not removed: %d", len(tm.Tasks()))
+	}
+}
+
+func TestCopyAskInTask(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	src, dst := filepath.Join(tmp, "src"), filepath.Join(tmp, "dst")
+	os.Mkdir(src, 0755)
+	os.Mkdir(dst, 0755)
+	ioutil.WriteFile(filepath.Join(src, "a"), []byte("new"), 0644)
+	ioutil.WriteFile(filepath.Join(dst, "a"), []byte("old"), 0644)
+
+	from, _ := Load(context.Background(), filepath.Join(src, "a"))
+	to, _ := Load(context.Background(), dst)
+	task, err := to.(DirOp).Write([]FileItem{from})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tm := NewTaskManager()
+	done := make(chan bool)
+	tm.Attach(NewTaskListener(nil, func(Task) { close(done) }, nil))
+	tm.Submit(task)
+
+	select {
+	case req := <-RequestCh:
+		if !req.IsBackground() {
+			t.Error("request of task should be in background")
+		}
+		req.Answer("y")
+	case <-time.After(2 * time.Second):
+		t.Fatal("no override request")
+	}
+	<-done
+
+	bs, _ := ioutil.ReadFile(filepath.Join(dst, "a"))
+	if string(bs) != "new" {
+		t.Errorf("file is not overridden: %s", bs)
+	}
+}
+
+// stuckItem a remote file whose data never comes, its reader is closed only when ctx is done
+type stuckItem struct {
+	FileItem
+	FileOp
+	path string
+}
+
+func (s *stuckItem) Name() string { return "stuck" }
+func (s *stuckItem) Path() string { return s.path }
+func (s *stuckItem) Size() int64  { return 1024 }
+func (s *stuckItem) IsDir() bool  { return false }
+
+func (s *stuckItem) Reader(ctx context.Context) (io.ReadCloser, error) {
+	r, w := io.Pipe()
+	return newReadCloser(r, closeOnDone(ctx, r), r, w), nil
+}
+
+func TestCancelBlockedCopy(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-cancel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	before := runtime.NumGoroutine()
+	to, _ := Load(context.Background(), tmp)
+	task, err := to.(DirOp).Write([]FileItem{&stuckItem{path: "/remote/stuck"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tm := NewTaskManager()
+	done := make(chan bool)
+	tm.Attach(NewTaskListener(nil, func(Task) { close(done) }, nil))
+	msg := tm.Submit(task)
+	time.Sleep(50 * time.Millisecond)
+	tm.Cancel(task)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the blocked copy is not canceled")
+	}
+	for range msg {
+	}
+
+	// the copy action returns as its reader is closed, nothing is left behind
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines are leaked", n-before)
+	}
+}
+
+func TestHistoryRetry(t *testing.T) {
+	var lock sync.Mutex
+	runs := make(map[string]int)
+	item := func(name string, fails int) Task {
+		return NewTask(name, func(ctx context.Context, progress chan<- int, err chan<- error) {
+			defer close(err)
+			defer close(progress)
+			lock.Lock()
+			runs[name]++
+			n := runs[name]
+			lock.Unlock()
+			if n <= fails {
+				err <- fmt.Errorf("%s failed", name)
+			}
+		})
+	}
+
+	tm := NewTaskManager()
+	finished := make(chan Task, 10)
+	tm.Attach(NewTaskListener(nil, func(task Task) { finished <- task }, nil))
+	run := func(task Task) *TaskRecord {
+		for range tm.Submit(task) {
+		}
+		<-finished
+		return tm.History()[0]
+	}
+
+	r := run(NewBatchTask("Copy", []Task{item("a", 0), item("b", 1), item("c", 0)}))
+	es := r.Errors()
+	if r.Status() != TaskFailed || len(es) != 1 || es[0].Task.Name() != "b" {
+		t.Fatalf("expect b failed, got %v %v", r.Status(), es)
+	}
+
+	retry := r.Retry()
+	if retry == nil || retry.Count() != 1 {
+		t.Fatal("expect only the failed item to be retried")
+	}
+	if r = run(retry); r.Status() != TaskCompleted {
+		t.Errorf("retry should complete, got %v %v", r.Status(), r.Errors())
+	}
+	if runs["a"] != 1 || runs["b"] != 2 || runs["c"] != 1 {
+		t.Errorf("unexpected runs: %v", runs)
+	}
+
+	task := stepTask("long", 1<<30)
+	tm.Submit(task)
+	tm.Cancel(task)
+	<-finished
+	if hs := tm.History(); len(hs) != 3 || hs[0].Status() != TaskCanceled || hs[0].Retry() != nil {
+		t.Errorf("expect the canceled task in history, got %v", hs[0].Status())
+	}
+}
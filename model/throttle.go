@@ -0,0 +1,170 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// limiter a token bucket limiting the bytes per second, a limiter with rate 0 does not limit
+// the bytes over the rate are a debt paid by waiting, so a chunk bigger than the rate is allowed
+type limiter struct {
+	rate   int64
+	tokens float64
+	last   time.Time
+	lock   *sync.Mutex
+}
+
+func newLimiter(rate int64) *limiter {
+	return &limiter{rate, 0, time.Time{}, new(sync.Mutex)}
+}
+
+func (l *limiter) setRate(rate int64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.rate, l.tokens, l.last = rate, 0, time.Time{}
+}
+
+func (l *limiter) getRate() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.rate
+}
+
+// reserve n bytes, return how long to wait before sending them
+func (l *limiter) reserve(n int) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.rate <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	}
+	// at most a second of bytes can be saved up
+	if max := float64(l.rate); l.tokens > max {
+		l.tokens = max
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+}
+
+// wait until n bytes can be sent, or ctx is done
+func (l *limiter) wait(ctx context.Context, n int) error {
+	d := l.reserve(n)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type limiterKey struct{}
+
+type loadersKey struct{}
+
+var (
+	limitLock = new(sync.Mutex)
+	limits    = make(map[string]*limiter)
+)
+
+// SetRateLimit limit the copies through loader to rate bytes per second, 0 for no limit
+// loader is the name of a loader such as ssh or file, the limit applies to all copies if it is empty
+func SetRateLimit(loader string, rate int64) {
+	limitLock.Lock()
+	defer limitLock.Unlock()
+	if l, ok := limits[loader]; ok {
+		l.setRate(rate)
+		return
+	}
+	limits[loader] = newLimiter(rate)
+}
+
+func sharedLimiter(loader string) *limiter {
+	limitLock.Lock()
+	defer limitLock.Unlock()
+	return limits[loader]
+}
+
+// limiterOf the limiter of the task running in ctx
+func limiterOf(ctx context.Context) *limiter {
+	l, _ := ctx.Value(limiterKey{}).(*limiter)
+	return l
+}
+
+// throughLoaders mark the copy in ctx going through the loaders of paths, the limits of them apply
+func throughLoaders(ctx context.Context, paths ...string) context.Context {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		pis, _ := ParsePath(p)
+		for _, v := range pis {
+			if !seen[v.Loader] {
+				seen[v.Loader] = true
+				names = append(names, v.Loader)
+			}
+		}
+	}
+	return context.WithValue(ctx, loadersKey{}, names)
+}
+
+// throttle wait until n more bytes can be copied under the limits of the task, its loaders and all copies
+func throttle(ctx context.Context, n int) error {
+	ls := []*limiter{limiterOf(ctx), sharedLimiter("")}
+	names, _ := ctx.Value(loadersKey{}).([]string)
+	for _, v := range names {
+		ls = append(ls, sharedLimiter(v))
+	}
+
+	for _, v := range ls {
+		if v == nil {
+			continue
+		}
+		if err := v.wait(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseRate parse a rate like 512K, 10M or 1G bytes per second, 0 means no limit
+func ParseRate(str string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(str), "/s"))
+	s = strings.TrimSuffix(s, "B")
+	unit := int64(1)
+	if len(s) > 0 {
+		switch s[len(s)-1] {
+		case 'K':
+			unit = 1024
+		case 'M':
+			unit = 1024 * 1024
+		case 'G':
+			unit = 1024 * 1024 * 1024
+		}
+		if unit != 1 {
+			s = s[:len(s)-1]
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("illegal rate: %s", str)
+	}
+	return int64(n * float64(unit)), nil
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestThrottleTransfer(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-throttle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	src, dst := filepath.Join(tmp, "src"), filepath.Join(tmp, "dst")
+	os.Mkdir(src, 0755)
+	os.Mkdir(dst, 0755)
+	ioutil.WriteFile(filepath.Join(src, "a"), make([]byte, 128*1024), 0644)
+	ioutil.WriteFile(filepath.Join(src, "b"), make([]byte, 128*1024), 0644)
+
+	copyAll := func(rate int64) time.Duration {
+		os.RemoveAll(dst)
+		os.Mkdir(dst, 0755)
+		a, _ := Load(context.Background(), filepath.Join(src, "a"))
+		b, _ := Load(context.Background(), filepath.Join(src, "b"))
+		to, _ := Load(context.Background(), dst)
+		task, err := to.(DirOp).Write([]FileItem{a, b})
+		if err != nil {
+			t.Fatal(err)
+		}
+		task.Throttle(rate)
+
+		started := time.Now()
+		done := make(chan bool)
+		tm := NewTaskManager()
+		tm.Attach(NewTaskListener(nil, func(Task) { close(done) }, nil))
+		tm.Submit(task)
+		<-done
+		return time.Since(started)
+	}
+
+	// the files in a batch share the limit of the batch
+	if d := copyAll(512 * 1024); d < 400*time.Millisecond {
+		t.Errorf("256K copied in %v under the limit of 512K/s", d)
+	}
+
+	SetRateLimit("file", 1024*1024)
+	defer SetRateLimit("file", 0)
+	if d := copyAll(0); d < 200*time.Millisecond {
+		t.Errorf("256K copied in %v under the loader limit of 1M/s", d)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	cases := []struct {
+		str  string
+		rate int64
+	}{
+		{"0", 0}, {"1000", 1000}, {"512K", 512 * 1024}, {"1.5m", 1536 * 1024}, {"2MB/s", 2 << 20}, {"1G", 1 << 30},
+	}
+	for _, c := range cases {
+		if rate, err := ParseRate(c.str); err != nil || rate != c.rate {
+			t.Errorf("%s: expect %d, got %d %v", c.str, c.rate, rate, err)
+		}
+	}
+	for _, v := range []string{"", "fast", "-1K"} {
+		if _, err := ParseRate(v); err == nil {
+			t.Errorf("%s: expect error", v)
+		}
+	}
+}
+
+func TestCopyDataCanceled(t *testing.T) {
+	progress := make(chan int, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := copyData(ctx, ioutil.Discard, &slowReader{1024}, newMeter(1024), progress); err != context.Canceled {
+		t.Errorf("expect the copy to be canceled, got %v", err)
+	}
+
+	// canceled while waiting for the rate limit
+	ctx = context.WithValue(context.Background(), limiterKey{}, newLimiter(1024))
+	ctx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := copyData(ctx, ioutil.Discard, &slowReader{64 * 1024}, newMeter(64*1024), progress); err != context.DeadlineExceeded {
+		t.Errorf("expect the throttled copy to be canceled, got %v", err)
+	}
+}
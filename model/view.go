@@ -0,0 +1,285 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	orderNames = map[Order]string{
+		OrderByName:  "name",
+		OrderByMTime: "mtime",
+		OrderBySize:  "size",
+	}
+)
+
+// reverseSuffix the suffix of the name of a reversed order
+const reverseSuffix = "-reverse"
+
+func (o Order) String() string {
+	if o.IsReverse() {
+		return orderNames[o.Base()] + reverseSuffix
+	}
+	return orderNames[o]
+}
+
+// ParseOrder parse an order name, such as mtime or mtime-reverse
+func ParseOrder(name string) (Order, error) {
+	reverse := strings.HasSuffix(name, reverseSuffix)
+	name = strings.TrimSuffix(name, reverseSuffix)
+	for k, v := range orderNames {
+		if v != name {
+			continue
+		}
+		if reverse {
+			k |= OrderReverse
+		}
+		return k, nil
+	}
+	return OrderByName, fmt.Errorf("unknown order: %s", name)
+}
+
+// ViewSetting how a dir is displayed
+type ViewSetting struct {
+	Order      Order
+	ShowHidden bool
+	ShowDetail bool
+	Filter     string
+}
+
+var defaultViewSetting = ViewSetting{OrderByName, false, false, ""}
+
+func (vs *ViewSetting) String() string {
+	return fmt.Sprintf("sort:%s hidden:%t detail:%t filter:%s", vs.Order, vs.ShowHidden, vs.ShowDetail, vs.Filter)
+}
+
+func parseViewSetting(str string) *ViewSetting {
+	vs := defaultViewSetting
+	str = strings.Trim(str, " \t")
+	for len(str) > 0 {
+		token := str
+		idx := strings.Index(str, " ")
+		if idx != -1 {
+			token = str[:idx]
+		}
+
+		ts := strings.SplitN(token, ":", 2)
+		if len(ts) == 2 && ts[0] == "filter" {
+			vs.Filter = strings.Trim(str[len("filter:"):], " \t")
+			break
+		}
+
+		if len(ts) == 2 {
+			switch ts[0] {
+			case "sort":
+				if o, err := ParseOrder(ts[1]); err == nil {
+					vs.Order = o
+				}
+			case "hidden":
+				vs.ShowHidden = ts[1] == "true"
+			case "detail":
+				vs.ShowDetail = ts[1] == "true"
+			}
+		}
+
+		if idx == -1 {
+			break
+		}
+		str = strings.TrimLeft(str[idx:], " \t")
+	}
+	return &vs
+}
+
+// ViewSettings remember view settings per path or path pattern
+//
+// A key is one of
+//
+//	/a/b/c     exactly the dir /a/b/c
+//	/a/b/**    the dir /a/b and all dirs inside it
+//	/a/*/c     a full path pattern, see filepath.Match
+//	Downloads  a pattern without separator, matched by dir name
+//
+// A key having = or quotes, or spaces around it, is saved as a go quoted string
+type ViewSettings struct {
+	path  string
+	keys  []string
+	items map[string]*ViewSetting
+
+	// the file is written in background by one writer, only the latest content is written if it changes quickly
+	lock    *sync.Mutex
+	writing *sync.WaitGroup
+	running bool
+	dirty   bool
+	pending []byte
+}
+
+// quoteViewKey quote key if it can not be read back as it is
+func quoteViewKey(key string) string {
+	if strings.ContainsAny(key, "=\"\\\n") || strings.HasPrefix(key, "#") || strings.TrimSpace(key) != key {
+		return strconv.Quote(key)
+	}
+	return key
+}
+
+// parseViewKey split line into the key and the setting, a quoted key is unquoted
+func parseViewKey(line string) (string, string, bool) {
+	if strings.HasPrefix(line, `"`) {
+		quoted, err := strconv.QuotedPrefix(line)
+		if err != nil {
+			return "", "", false
+		}
+		key, _ := strconv.Unquote(quoted)
+		rest := strings.TrimLeft(line[len(quoted):], " \t")
+		if !strings.HasPrefix(rest, "=") {
+			return "", "", false
+		}
+		return key, rest[1:], true
+	}
+
+	idx := strings.Index(line, "=")
+	if idx == -1 {
+		return "", "", false
+	}
+	key := strings.Trim(line[:idx], " \t")
+	if strings.HasPrefix(key, "~/") {
+		key = filepath.Join(home, key[2:])
+	}
+	return key, line[idx+1:], true
+}
+
+func (v *ViewSettings) write() {
+	s := ""
+	for _, k := range v.keys {
+		s = fmt.Sprintf("%s%s = %s\n", s, quoteViewKey(k), v.items[k])
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.pending, v.dirty = []byte(s), true
+	if v.running {
+		return
+	}
+	v.running = true
+	v.writing.Add(1)
+	go v.flush()
+}
+
+// flush write the pending content until it is not changed again
+func (v *ViewSettings) flush() {
+	defer v.writing.Done()
+	for {
+		v.lock.Lock()
+		if !v.dirty {
+			v.running = false
+			v.lock.Unlock()
+			return
+		}
+		bs := v.pending
+		v.pending, v.dirty = nil, false
+		v.lock.Unlock()
+
+		dir := filepath.Dir(v.path)
+		if _, err := os.Stat(dir); err != nil {
+			os.MkdirAll(dir, 0755)
+		}
+		ioutil.WriteFile(v.path, bs, 0644)
+	}
+}
+
+// Flush wait until the saved settings are written
+func (v *ViewSettings) Flush() {
+	if v != nil {
+		v.writing.Wait()
+	}
+}
+
+func (v *ViewSettings) read() {
+	bs, err := ioutil.ReadFile(v.path)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(bs), "\n") {
+		line = strings.Trim(line, " \t")
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if key, setting, ok := parseViewKey(line); ok {
+			v.set(key, parseViewSetting(setting))
+		}
+	}
+}
+
+func (v *ViewSettings) set(key string, vs *ViewSetting) {
+	if _, has := v.items[key]; !has {
+		v.keys = append(v.keys, key)
+	}
+	v.items[key] = vs
+}
+
+func matchView(key, path string) bool {
+	if key == path {
+		return true
+	}
+
+	if strings.HasSuffix(key, "/**") {
+		prefix := key[:len(key)-3]
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+
+	if !strings.Contains(key, "/") {
+		m, _ := filepath.Match(key, filepath.Base(path))
+		return m
+	}
+
+	m, _ := filepath.Match(key, path)
+	return m
+}
+
+// Get the setting for path, an exact path wins, then the longest matched pattern
+func (v *ViewSettings) Get(path string) *ViewSetting {
+	if v == nil {
+		vs := defaultViewSetting
+		return &vs
+	}
+
+	if vs, has := v.items[path]; has {
+		return vs
+	}
+
+	var found *ViewSetting
+	length := -1
+	for _, k := range v.keys {
+		if len(k) > length && matchView(k, path) {
+			found, length = v.items[k], len(k)
+		}
+	}
+
+	if found == nil {
+		vs := defaultViewSetting
+		return &vs
+	}
+	return found
+}
+
+// Save the current view of column for its path, the file is written in background
+func (v *ViewSettings) Save(co Column) {
+	if v == nil {
+		return
+	}
+
+	v.set(co.Path(), &ViewSetting{co.Order(), co.IsShowHidden(), co.IsShowDetail(), co.Filter()})
+	v.write()
+}
+
+// NewViewSettings create view settings
+func NewViewSettings(path string) *ViewSettings {
+	v := &ViewSettings{path, nil, make(map[string]*ViewSetting), new(sync.Mutex), new(sync.WaitGroup), false, false, nil}
+	v.read()
+	return v
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestViewSettingsGet(t *testing.T) {
+	v := NewViewSettings("")
+	v.set("/home/a/src/**", &ViewSetting{OrderByMTime, false, false, ""})
+	v.set("/home/a/src/fff", &ViewSetting{OrderBySize, true, false, ""})
+	v.set("Downloads", &ViewSetting{OrderByMTime, false, true, ">100m"})
+	v.set("/home/*/Downloads", &ViewSetting{OrderBySize, false, true, ""})
+
+	cases := []struct {
+		path  string
+		order Order
+	}{
+		{"/home/a/src", OrderByMTime},
+		{"/home/a/src/go/fff", OrderByMTime},
+		{"/home/a/src/fff", OrderBySize},
+		{"/home/a/srcs", OrderByName},
+		{"/mnt/Downloads", OrderByMTime},
+		{"/home/b/Downloads", OrderBySize},
+	}
+	for _, c := range cases {
+		if o := v.Get(c.path).Order; o != c.order {
+			t.Errorf("%s: expect order %d, got %d", c.path, c.order, o)
+		}
+	}
+}
+
+func TestParseViewSetting(t *testing.T) {
+	vs := parseViewSetting(" sort:size hidden:true detail:false filter::f >1m go")
+	if vs.Order != OrderBySize || !vs.ShowHidden || vs.ShowDetail || vs.Filter != ":f >1m go" {
+		t.Errorf("incorrect setting: %s", vs)
+	}
+
+	if parseViewSetting(vs.String()).String() != vs.String() {
+		t.Errorf("setting can not be read back: %s", vs)
+	}
+
+	vs = parseViewSetting("sort:mtime-reverse")
+	if vs.Order != OrderByMTime|OrderReverse || parseViewSetting(vs.String()).Order != vs.Order {
+		t.Errorf("reversed order is not read: %s", vs)
+	}
+}
+
+func TestViewSettingsSave(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "fff-views")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "views")
+
+	v := NewViewSettings(path)
+	keys := []string{"/tmp/a = b", `/tmp/"c"`, " /tmp/d", "/tmp/e"}
+	for i, k := range keys {
+		v.set(k, &ViewSetting{OrderBySize, i%2 == 0, false, "x = y"})
+		v.write()
+	}
+	v.Flush()
+
+	r := NewViewSettings(path)
+	if len(r.keys) != len(keys) {
+		bs, _ := ioutil.ReadFile(path)
+		t.Fatalf("expect %d keys, got %q from\n%s", len(keys), r.keys, bs)
+	}
+	for i, k := range keys {
+		vs, ok := r.items[k]
+		if !ok || vs.Order != OrderBySize || vs.ShowHidden != (i%2 == 0) || vs.Filter != "x = y" {
+			t.Errorf("%q is not read back: %v", k, vs)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher notify the changes of dirs
+// local dirs are watched by inotify(fsnotify), others (ssh) are polled if the poll interval is not 0
+// changes in the debounce duration are sent to C together
+type Watcher struct {
+	C chan []string
+
+	fs       *fsnotify.Watcher
+	debounce time.Duration
+	interval time.Duration
+	lock     *sync.Mutex
+	dirs     map[string]FileItem
+	stamps   map[string]string
+	pending  map[string]bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewWatcher create watcher
+func NewWatcher(debounce, interval time.Duration) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		make(chan []string), fs, debounce, interval, new(sync.Mutex),
+		make(map[string]FileItem), make(map[string]string), make(map[string]bool), ctx, cancel,
+	}
+	go w.watch()
+	if interval > 0 {
+		go w.poll()
+	}
+	return w, nil
+}
+
+func isLocalDir(item FileItem) bool {
+	_, ok := item.(*dir)
+	return ok
+}
+
+// Watch set the dirs to watch, dirs not in items are not watched any more
+func (w *Watcher) Watch(items []FileItem) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	mp := make(map[string]FileItem, len(items))
+	for _, v := range items {
+		// archives do not change without a refresh of the archive file
+		if _, ok := v.(archiveItem); !ok {
+			mp[v.Path()] = v
+		}
+	}
+
+	for k, v := range w.dirs {
+		if _, ok := mp[k]; ok {
+			continue
+		}
+		if isLocalDir(v) {
+			w.fs.Remove(k)
+		}
+		delete(w.dirs, k)
+		delete(w.stamps, k)
+	}
+
+	for k, v := range mp {
+		if _, ok := w.dirs[k]; ok {
+			continue
+		}
+		if isLocalDir(v) {
+			if err := w.fs.Add(k); err != nil {
+				continue
+			}
+		}
+		w.dirs[k] = v
+	}
+}
+
+// changed mark path as changed, the first change starts the debounce timer
+func (w *Watcher) changed(path string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, ok := w.dirs[path]; !ok {
+		return
+	}
+	if len(w.pending) == 0 {
+		time.AfterFunc(w.debounce, w.flush)
+	}
+	w.pending[path] = true
+}
+
+func (w *Watcher) flush() {
+	w.lock.Lock()
+	paths := make([]string, 0, len(w.pending))
+	for k := range w.pending {
+		paths = append(paths, k)
+	}
+	w.pending = make(map[string]bool)
+	w.lock.Unlock()
+
+	select {
+	case w.C <- paths:
+	case <-w.ctx.Done():
+	}
+}
+
+func (w *Watcher) watch() {
+	for {
+		select {
+		case ev, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			w.changed(filepath.Dir(ev.Name))
+			w.changed(ev.Name)
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// stamp a summary of items, it changes if any item is added, removed or modified
+func stamp(items []FileItem) string {
+	s := ""
+	for _, v := range items {
+		s += fmt.Sprintf("%s|%d|%d\n", v.Name(), v.Size(), v.ModTime().UnixNano())
+	}
+	return s
+}
+
+func (w *Watcher) poll() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.ctx.Done():
+			return
+		}
+
+		w.lock.Lock()
+		remotes := make(map[string]FileItem)
+		for k, v := range w.dirs {
+			if !isLocalDir(v) {
+				remotes[k] = v
+			}
+		}
+		w.lock.Unlock()
+
+		for k, v := range remotes {
+			op, ok := v.(DirOp)
+			if !ok || !v.IsDir() {
+				continue
+			}
+			items, err := op.Read(w.ctx)
+			if err != nil {
+				continue
+			}
+
+			st := stamp(items)
+			w.lock.Lock()
+			old, ok := w.stamps[k]
+			if _, watched := w.dirs[k]; watched {
+				w.stamps[k] = st
+			}
+			w.lock.Unlock()
+
+			if ok && old != st {
+				w.changed(k)
+			}
+		}
+	}
+}
+
+// Close stop watching
+func (w *Watcher) Close() {
+	w.cancel()
+	w.fs.Close()
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	item, err := Load(context.Background(), tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWatcher(50*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Watch([]FileItem{item})
+
+	for i := 0; i < 10; i++ {
+		ioutil.WriteFile(filepath.Join(tmp, "a"), []byte{byte(i)}, 0644)
+	}
+
+	select {
+	case paths := <-w.C:
+		if len(paths) != 1 || paths[0] != item.Path() {
+			t.Errorf("expect changes of %s in one batch, got %v", item.Path(), paths)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no change received")
+	}
+
+	w.Watch(nil)
+	ioutil.WriteFile(filepath.Join(tmp, "b"), nil, 0644)
+	select {
+	case paths := <-w.C:
+		t.Errorf("unwatched dir changed: %v", paths)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestColumnReload(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fff-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, v := range []string{"a", "b", "c"} {
+		ioutil.WriteFile(filepath.Join(tmp, v), nil, 0644)
+	}
+	item, _ := Load(context.Background(), tmp)
+	co, err := NewLocalColumn(item, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	co.SelectByName("c")
+	co.Mark(1)
+
+	os.Remove(filepath.Join(tmp, "a"))
+	items, _ := item.(DirOp).Read(context.Background())
+	co.Reload(items)
+
+	fi, _ := co.CurrentFile()
+	marked := co.Marked()
+	if fi.Name() != "c" || len(marked) != 1 || marked[0].Name() != "b" {
+		t.Errorf("selection or marks lost: selected %s, marked %v", fi.Name(), marked)
+	}
+}
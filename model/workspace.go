@@ -4,28 +4,54 @@ import (
 	"path/filepath"
 )
 
-// Workspace hold all state
+const (
+	// recentPaths the number of the recent paths kept
+	recentPaths = 50
+
+	// recentInputs the number of the inputs kept for each kind of input
+	recentInputs = 100
+)
+
+// Workspace hold all state, it is not safe for concurrent use, all changes should be made by its owner
 type Workspace struct {
 	Groups         []Group
 	Clip           []FileItem
 	Tm             *TaskManager
 	Current        int
 	Bookmark       *Bookmark
+	Views          *ViewSettings
+	Paths          *Recent
+	dataDir        string
+	inputs         map[string]*Recent
 	showBookmark   bool
 	showClipDetail bool
 	showTaskDetail bool
+	showPreview    bool
 }
 
 // NewWorkspace create workspace
-func NewWorkspace(maxGroups int, wd, configDir string) *Workspace {
+// the bookmarks, views, recent paths and inputs, and journals of copies are kept in dataDir
+func NewWorkspace(maxGroups int, wd, dataDir string, showPreview bool) *Workspace {
+	views := NewViewSettings(filepath.Join(dataDir, "views"))
+	journalDir = filepath.Join(dataDir, "transfers")
 	gs := make([]Group, maxGroups)
-	g, err := NewLocalGroup(wd)
+	g, err := NewLocalGroup(wd, views)
 	if err != nil {
 		panic(err)
 	}
 	gs[0] = g
 
-	return &Workspace{gs, nil, NewTaskManager(), 0, NewBookmark(filepath.Join(configDir, "bookmarks")), true, false, false}
+	return &Workspace{gs, nil, NewTaskManager(), 0, NewBookmark(filepath.Join(dataDir, "bookmarks")), views, NewRecent(filepath.Join(dataDir, "paths"), recentPaths), dataDir, make(map[string]*Recent), true, false, false, showPreview}
+}
+
+// Inputs the history of the inputs of kind, such as filter, it is read when first used
+func (w *Workspace) Inputs(kind string) *Recent {
+	if r, ok := w.inputs[kind]; ok {
+		return r
+	}
+	r := NewRecent(filepath.Join(w.dataDir, "inputs", kind), recentInputs)
+	w.inputs[kind] = r
+	return r
 }
 
 // CurrentGroup get the current group in use
@@ -75,3 +101,13 @@ func (w *Workspace) IsShowTaskDetail() bool {
 func (w *Workspace) ShowTaskDetail(show bool) {
 	w.showTaskDetail = show
 }
+
+// IsShowPreview if to show preview of the selected item
+func (w *Workspace) IsShowPreview() bool {
+	return w.showPreview
+}
+
+// TogglePreview toggle preview
+func (w *Workspace) TogglePreview() {
+	w.showPreview = !w.showPreview
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"archive/zip"
+	"context"
 	"io"
 	"path"
 	"strings"
@@ -14,8 +15,8 @@ var (
 	_ = DirOp(new(zipdir))
 )
 
-func openZip(a archive) (io.Closer, *zip.Reader, error) {
-	in, err := a.origin().(FileOp).Reader()
+func openZip(ctx context.Context, a archive) (io.Closer, *zip.Reader, error) {
+	in, err := a.origin().(FileOp).Reader(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -38,8 +39,8 @@ func newZipfile(ai archiveItem) *zipfile {
 	return &zipfile{&archiveFileOp{&archiveOp{ai}}}
 }
 
-func (zf *zipfile) Reader() (io.ReadCloser, error) {
-	file, reader, err := openZip(zf.archive())
+func (zf *zipfile) Reader(ctx context.Context) (io.ReadCloser, error) {
+	file, reader, err := openZip(ctx, zf.archive())
 	if err != nil {
 		return nil, err
 	}
@@ -74,9 +75,9 @@ func (zl *zipLoader) Support(item FileItem) bool {
 	return !item.IsDir() && strings.HasSuffix(item.Name(), ".zip")
 }
 
-func (zl *zipLoader) Create(item FileItem) (FileItem, error) {
+func (zl *zipLoader) Create(ctx context.Context, item FileItem) (FileItem, error) {
 	ar := &defaultArchive{zl, item, nil, nil, nil}
-	file, reader, err := openZip(ar)
+	file, reader, err := openZip(ctx, ar)
 	if err != nil {
 		return nil, err
 	}
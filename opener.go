@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+// opener a rule of openers in config, it matches a file if all of its conditions match, an empty condition matches any file
+// ext the extensions, glob the patterns of the name, mime the types such as image/*, loader the names such as zip
+type opener struct {
+	exts       []string
+	globs      []string
+	mimes      []string
+	loaders    []string
+	programs   []string
+	background bool
+}
+
+// openProgram a program of the matched rules, it runs out of the terminal if background
+type openProgram struct {
+	program    string
+	background bool
+}
+
+func matchAny(patterns []string, fn func(string) bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, v := range patterns {
+		if fn(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// match the rule against item, mimeType is only called if the rule has mime types
+func (o *opener) match(item model.FileItem, mimeType func() string) bool {
+	name := item.Name()
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	return matchAny(o.exts, func(v string) bool { return strings.EqualFold(strings.TrimPrefix(v, "."), ext) }) &&
+		matchAny(o.globs, func(v string) bool { ok, _ := filepath.Match(v, name); return ok }) &&
+		matchAny(o.loaders, func(v string) bool { return v == model.LoaderName(item) }) &&
+		matchAny(o.mimes, func(v string) bool { ok, _ := path.Match(v, mimeType()); return ok })
+}
+
+// matchOpeners the programs of the rules matching item in order, a program is listed once
+// the content of item is read for its mime type if any rule needs it
+func matchOpeners(ctx context.Context, rules []*opener, item model.FileItem) []openProgram {
+	mt, detected := "", false
+	mimeType := func() string {
+		if !detected {
+			mt, detected = model.DetectMime(ctx, item), true
+		}
+		return mt
+	}
+
+	re := make([]openProgram, 0)
+	seen := make(map[string]bool)
+	for _, v := range rules {
+		if !v.match(item, mimeType) {
+			continue
+		}
+		for _, p := range v.programs {
+			if !seen[p] {
+				re = append(re, openProgram{p, v.background})
+				seen[p] = true
+			}
+		}
+	}
+	return re
+}
+
+// findPrograms match the rules against item in background, since the content may be read from a remote server
+// found is called in the state loop
+func findPrograms(item model.FileItem, found func([]openProgram)) {
+	rules, timeout := cfg.openers, cfg.loadTimeout
+	startJob(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		ps := matchOpeners(ctx, rules, item)
+		post(func() { found(ps) })
+	})
+}
+
+// programScript the script running program with path, path is appended if there is no {file} in program
+func programScript(program, path string) string {
+	if strings.Contains(program, "{file}") {
+		return strings.Replace(program, "{file}", shellQuote(path), -1)
+	}
+	return program + " " + shellQuote(path)
+}
+
+// openWith run p with item, a file not in the local file system is fetched to a temp dir by a task first
+func openWith(item model.FileItem, p openProgram) {
+	ws := strings.Fields(p.program)
+	if len(ws) == 0 {
+		return
+	}
+	runMode := runForeground
+	if p.background {
+		runMode = runSilent
+	}
+	run := func(path string) {
+		if err := runScript(ws[0], filepath.Dir(path), programScript(p.program, path), runMode, false); err != nil {
+			ui.MessageEvent.Send(err.Error())
+		}
+	}
+
+	if model.LoaderName(item) == "file" {
+		run(item.Path())
+		return
+	}
+
+	// the copy is a task, it can be canceled and its progress is shown in the task list
+	submitTask(model.NewFetchTask(item, func(path string) {
+		post(func() {
+			if !stopping {
+				run(path)
+			}
+		})
+	}))
+}
+
+// openByRules open item by the first program of the matched rules, or by the system opener if none matches
+func openByRules(item model.FileItem) {
+	if item.IsDir() || len(cfg.openers) == 0 {
+		openBySystem(item)
+		return
+	}
+	findPrograms(item, func(ps []openProgram) {
+		if len(ps) == 0 {
+			openBySystem(item)
+			return
+		}
+		openWith(item, ps[0])
+	})
+}
+
+func openBySystem(item model.FileItem) {
+	if err := item.(model.Op).Open(); err != nil {
+		ui.MessageEvent.Send(err.Error())
+	}
+}
+
+// openWithInputer the input of open with, the programs of the matched rules are the candidates
+// a program not in the list runs in the terminal
+type openWithInputer struct {
+	*nameInputer
+	programs []openProgram
+}
+
+func newOpenWithInput(item model.FileItem, programs []openProgram) *openWithInputer {
+	oi := &openWithInputer{nil, programs}
+	oi.nameInputer = newRecordedInput("OPEN WITH", "open-with", func(program string) {
+		p := openProgram{program, false}
+		for _, v := range oi.programs {
+			if v.program == program {
+				p = v
+			}
+		}
+		openWith(item, p)
+	})
+	return oi
+}
+
+func (oi *openWithInputer) Complete(ctx context.Context, dir model.FileItem, before string) ([]string, error) {
+	re := make([]string, 0)
+	for _, v := range oi.programs {
+		if strings.HasPrefix(v.program, before) {
+			re = append(re, v.program)
+		}
+	}
+	return re, nil
+}
+
+// startOpenWith ask for the program to open the selected file, the matched programs are listed at once
+func startOpenWith() {
+	item, err := wo.CurrentGroup().Current().CurrentFile()
+	if err != nil {
+		ui.MessageEvent.Send("no file selected")
+		return
+	}
+	if item.IsDir() {
+		ui.MessageEvent.Send(item.Name() + " is a dir")
+		return
+	}
+
+	findPrograms(item, func(ps []openProgram) {
+		if mode != ModeNormal {
+			return
+		}
+		enterInputMode(newOpenWithInput(item, ps))
+		if len(ps) != 0 {
+			inputComplete()
+		}
+	})
+}
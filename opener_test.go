@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+func TestLoopOpener(t *testing.T) {
+	tmp := tempDir(t, "a.txt")
+	defer os.RemoveAll(tmp)
+	model.SetDefault("sh", "less", "vi")
+	startLoop(tmp)
+
+	// the programs run in background, they are done with the jobs waiting for them
+	checkFile := func(name string) {
+		waitJobs(0)
+		if bs, err := ioutil.ReadFile(filepath.Join(tmp, name)); err != nil || string(bs) != "a.txt" {
+			t.Errorf("%s is not written: %q %v", name, bs, err)
+		}
+	}
+
+	post(func() {
+		cfg.openers = []*opener{
+			{[]string{"jpg"}, nil, nil, nil, []string{"false"}, true},
+			{nil, nil, []string{"text/*"}, []string{"file"}, []string{"cp {file} first", "cp {file} second"}, true},
+			{nil, []string{"*.txt"}, nil, nil, []string{"cp {file} second", "false"}, false},
+		}
+		co := wo.CurrentGroup().Current()
+		co.SelectByName("a.txt")
+		file, _ := co.CurrentFile()
+		ps := matchOpeners(context.Background(), cfg.openers, file)
+		if fmt.Sprint(ps) != "[{cp {file} first true} {cp {file} second true} {false false}]" {
+			t.Errorf("unexpected programs %v", ps)
+		}
+	})
+	waitLoop()
+
+	sendKey(termbox.KeyEnter)
+	checkFile("first")
+
+	// the chooser selects the first program, tab goes to the next one
+	sendKeys("O")
+	waitJobs(0)
+	sendKey(termbox.KeyTab)
+	sendKey(termbox.KeyEnter)
+	checkFile("second")
+	post(func() {
+		cfg.openers = nil
+		if mode != ModeNormal {
+			t.Errorf("expect normal mode, got %v", mode)
+		}
+	})
+	waitLoop()
+}
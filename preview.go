@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+// wait a while before loading, so that holding j/k does not load every file passed by
+const previewDelay = 80 * time.Millisecond
+
+// the preview state is owned by the state loop
+var (
+	// loadPreview read the preview of an item, tests replace it to control when a preview is done
+	loadPreview = model.LoadPreview
+
+	previewCancel  context.CancelFunc
+	previewPath    string
+	previewModTime time.Time
+)
+
+func cancelPreview() {
+	if previewCancel != nil {
+		previewCancel()
+		previewCancel = nil
+	}
+}
+
+// updatePreview load the preview of the selected item if it changed
+// it is loaded in background, the result is shown in the state loop if the item is still previewed
+func updatePreview() {
+	if !wo.IsShowPreview() {
+		cancelPreview()
+		previewPath = ""
+		return
+	}
+
+	fi, err := wo.CurrentGroup().Current().CurrentFile()
+	if err != nil {
+		cancelPreview()
+		previewPath = ""
+		return
+	}
+
+	if fi.Path() == previewPath && fi.ModTime().Equal(previewModTime) {
+		return
+	}
+
+	cancelPreview()
+	ctx, cancel := context.WithCancel(context.Background())
+	previewCancel = cancel
+	previewPath = fi.Path()
+	previewModTime = fi.ModTime()
+	lines := gui.Column.Height
+
+	startJob(func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(previewDelay):
+		}
+
+		pv, err := loadPreview(ctx, fi, lines)
+		post(func() {
+			if ctx.Err() != nil || previewPath != fi.Path() {
+				return
+			}
+			if err != nil {
+				ui.PreviewEvent.Send(err)
+				return
+			}
+			ui.PreviewEvent.Send(pv)
+		})
+	})
+}
+
+// resetPreview force reload the preview
+func resetPreview() {
+	previewPath = ""
+	updatePreview()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+func TestLoopPreviewCanceled(t *testing.T) {
+	tmp := tempDir(t, "a", "b")
+	defer os.RemoveAll(tmp)
+	startLoop(tmp)
+
+	started, release := make(chan bool), make(chan bool)
+	canceled := make(chan bool, 1)
+	loadPreview = func(ctx context.Context, item model.FileItem, lines int) (*model.Preview, error) {
+		if item.Name() == "a" {
+			close(started)
+			<-release
+			canceled <- ctx.Err() != nil
+		}
+		return &model.Preview{Item: item, Type: model.PreviewText, Lines: []string{item.Name()}}, nil
+	}
+	shown := make(chan string, 4)
+	hookEvents(func(ev ui.Event) {
+		if pv, ok := ev.Data.(*model.Preview); ok && ev.Type == ui.PreviewEvent {
+			shown <- pv.Item.Name()
+		}
+	})
+	defer func() {
+		hookEvents(nil)
+		loadPreview = model.LoadPreview
+		post(func() { gui = nil })
+		waitLoop()
+	}()
+
+	post(func() {
+		gui = &ui.UI{Column: &ui.Column{Height: 10}}
+		wo.TogglePreview()
+		updatePreview()
+	})
+	<-started
+
+	// b is selected while a is being loaded, the preview of a is dropped
+	sendKeys("j")
+	if name := <-shown; name != "b" {
+		t.Errorf("expect preview of b, got %s", name)
+	}
+	close(release)
+	if !<-canceled {
+		t.Error("the preview of a is not canceled")
+	}
+
+	waitJobs(0)
+	select {
+	case name := <-shown:
+		t.Errorf("stale preview of %s is shown", name)
+	default:
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	// configStamp the mtime and size of the config file when it is read last time
+	configStamp string
+
+	// reloadPending the config file is changed when the ui is closed or not in normal mode
+	// it is reloaded when the ui is back to normal mode, so that the popups and inputs are not redrawn
+	reloadPending bool
+)
+
+// configFileStamp the mtime and size of the config file of user, empty if it does not exist
+func configFileStamp() string {
+	fi, err := os.Stat(userConfig())
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", fi.ModTime().UnixNano(), fi.Size())
+}
+
+// watchConfig reload the config when the config file of user is changed
+// the dir of the file is watched, so the file is found after it is created or replaced by an editor
+func watchConfig() {
+	configStamp = configFileStamp()
+	if !cfg.watch {
+		return
+	}
+	dir, err := model.Load(context.Background(), filepath.Dir(userConfig()))
+	if err != nil {
+		return
+	}
+	w, err := model.NewWatcher(watchDebounce, 0)
+	if err != nil {
+		return
+	}
+	w.Watch([]model.FileItem{dir})
+
+	go func() {
+		for range w.C {
+			post(func() {
+				if configFileStamp() == configStamp {
+					return
+				}
+				reloadPending = true
+				reloadIfPending()
+			})
+		}
+	}()
+}
+
+func reloadIfPending() {
+	if reloadPending && !stopping && mode == ModeNormal {
+		reloadConfig()
+	}
+}
+
+// reloadConfig read the config again and apply the key bindings, colors, programs and limits of it
+// the workspace is kept, the config is not changed if the config file can not be parsed
+func reloadConfig() {
+	configStamp, reloadPending = configFileStamp(), false
+	if ds, err := ioutil.ReadFile(userConfig()); err == nil {
+		var mp map[string]interface{}
+		if yaml.Unmarshal(ds, &mp) != nil {
+			ui.MessageEvent.Send(checkConfig(ds)[0].format(filepath.Base(userConfig())) + ", the config is not reloaded")
+			return
+		}
+	}
+
+	old := cfg
+	cfg = initConfig()
+	ui.SetColors(cfg.colors)
+	model.SetDefault(cfg.shell, cfg.pager, cfg.editor)
+	for k := range old.rateLimits {
+		if _, ok := cfg.rateLimits[k]; !ok {
+			cfg.rateLimits[k] = 0
+		}
+	}
+	cfg.applyCopy()
+	restoreKbds()
+	updateMaxColumns()
+	if gui != nil {
+		recreateUI()
+	}
+
+	msg := "Config reloaded"
+	if err := configWarning(); err != nil {
+		msg = err.Error()
+	}
+	ui.MessageEvent.Send(msg)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+func TestLoopReloadConfig(t *testing.T) {
+	tmp := tempDir(t, "big", "small")
+	defer os.RemoveAll(tmp)
+	ioutil.WriteFile(filepath.Join(tmp, "big"), make([]byte, 100), 0644)
+	startLoop(tmp)
+
+	old, oldFile := cfg, configFile
+	configFile = filepath.Join(tmp, "fff.yml")
+	defer func() {
+		post(func() {
+			cfg, configFile = old, oldFile
+			restoreKbds()
+		})
+		waitLoop()
+	}()
+	ioutil.WriteFile(configFile, []byte("binding:\n  normal:\n    \"z\": \":sort size\"\n"), 0644)
+
+	sendKey(termbox.KeyCtrlR)
+	sendKeys("z")
+	post(func() {
+		if o := wo.CurrentGroup().Current().Order(); o != model.OrderBySize {
+			t.Errorf("expect the new binding sorts by size, got %s", o)
+		}
+		if cfg == old || cfg.shell != old.shell {
+			t.Error("expect a new config with the same defaults")
+		}
+	})
+	waitLoop()
+
+	// a broken file keeps the config
+	ioutil.WriteFile(configFile, []byte("binding: [\n"), 0644)
+	post(func() {
+		reloaded := cfg
+		reloadConfig()
+		if cfg != reloaded {
+			t.Error("expect the config is kept")
+		}
+	})
+	waitLoop()
+}
@@ -46,3 +46,9 @@ func colorFilter() *Color         { return getColor("filter") }
 func colorStatusBarTitle() *Color { return getColor("statusbar-title") }
 func colorClip() *Color           { return getColor("clip") }
 func colorProgress() *Color       { return getColor("progress") }
+func colorCodeKeyword() *Color    { return getColor("code-keyword") }
+func colorCodeString() *Color     { return getColor("code-string") }
+func colorCodeComment() *Color    { return getColor("code-comment") }
+func colorCodeNumber() *Color     { return getColor("code-number") }
+func colorCodeType() *Color       { return getColor("code-type") }
+func colorCodeKey() *Color        { return getColor("code-key") }
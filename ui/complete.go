@@ -0,0 +1,57 @@
+package ui
+
+import (
+	termbox "github.com/nsf/termbox-go"
+)
+
+// maxCompletions the most candidates shown at once
+const maxCompletions = 10
+
+// CompletionData the candidates of tab, Selected is the one in the input
+type CompletionData struct {
+	Candidates []string
+	Selected   int
+}
+
+// Completion popup of the candidates, it is shown above the input
+type Completion struct {
+	list   *List
+	popup  *Popup
+	opened bool
+}
+
+// NewCompletion create completion popup
+func NewCompletion() *Completion {
+	list := NewList(ZeroPoint, -1, 0, make([]string, 0), make([]int, 0))
+	return &Completion{list, NewPopup(ZeroPoint, NewBox(ZeroPoint, list)), false}
+}
+
+// Open the popup with data, it starts from x if there is enough room
+func (c *Completion) Open(x int, data *CompletionData) {
+	c.Close()
+	h := len(data.Candidates)
+	if h > maxCompletions {
+		h = maxCompletions
+	}
+	c.list.SetData(data.Candidates, make([]int, len(data.Candidates)), data.Selected)
+	c.list.Height = h
+
+	tw, th := termbox.Size()
+	w, _ := measure(c.popup.Item)
+	if x+w > tw {
+		x = tw - w
+	}
+	if x < 0 {
+		x = 0
+	}
+	Move(c.popup, &Point{x, th - h - 3})
+	c.opened = true
+}
+
+// Close popup
+func (c *Completion) Close() {
+	if c.opened {
+		c.popup.Clear()
+		c.opened = false
+	}
+}
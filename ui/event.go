@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/jacokoo/fff/model"
+	"github.com/mattn/go-runewidth"
 	termbox "github.com/nsf/termbox-go"
 )
 
@@ -50,7 +51,7 @@ const (
 	// ToggleBookmarkEvent Data: bool if to show bookmark
 	ToggleBookmarkEvent
 
-	// InputChangeEvent Data: [name string, value string]
+	// InputChangeEvent Data: *InputData
 	InputChangeEvent
 
 	// QuitInputEvent Data: model.Column
@@ -77,6 +78,27 @@ const (
 	// ToggleClipDetailEvent Data: bool
 	ToggleClipDetailEvent
 
+	// TogglePreviewEvent Data: bool
+	TogglePreviewEvent
+
+	// PreviewEvent Data: *model.Preview or error
+	PreviewEvent
+
+	// HexEvent Data: *HexData, nil to close the hex view
+	HexEvent
+
+	// ColumnsRefreshEvent Data: model.Group, the content of columns changed
+	ColumnsRefreshEvent
+
+	// HistoryEvent Data: *HistoryData, nil to close the history view
+	HistoryEvent
+
+	// SumsEvent Data: *SumsData, nil to close the checksum view
+	SumsEvent
+
+	// CompletionEvent Data: *CompletionData, nil to close the candidates
+	CompletionEvent
+
 	changeCurrent
 )
 
@@ -220,6 +242,12 @@ func init() {
 			initFiles(ui.isShowBookmark(), data.(model.Group))
 		},
 
+		ColumnsRefreshEvent: func(data interface{}) {
+			g := data.(model.Group)
+			initFiles(ui.isShowBookmark(), g)
+			setFileInfo(g.Current())
+		},
+
 		ChangeRootEvent: func(data interface{}) {
 			g := data.(model.Group)
 			initFiles(ui.isShowBookmark(), g)
@@ -237,15 +265,20 @@ func init() {
 		},
 
 		InputChangeEvent: func(data interface{}) {
-			ss := data.([]string)
+			d := data.(*InputData)
 			st := ui.StatusInput.Restore()
-			st.Set(0, fmt.Sprintf(" %s ", ss[0]))
-			p := st.Set(1, ss[1])
-			x := p.X
-			if len(ss[1]) != 0 {
-				x++
+			st.Set(0, fmt.Sprintf(" %s ", d.Name))
+			st.Set(1, d.Value)
+
+			rs := []rune(d.Value)
+			p := st.items[1].Start
+			if d.SelFrom < d.SelTo {
+				t := NewText(p.RightN(runewidth.StringWidth(string(rs[:d.SelFrom]))), string(rs[d.SelFrom:d.SelTo]))
+				// the selection flips the colors of the bar
+				t.Color = &Color{FG: st.Color.FG, BG: st.Color.BG ^ termbox.AttrReverse}
+				t.Draw()
 			}
-			termbox.SetCursor(x, p.Y)
+			termbox.SetCursor(p.X+runewidth.StringWidth(string(rs[:d.Cursor])), p.Y)
 		},
 
 		QuitInputEvent: func(data interface{}) {
@@ -327,8 +360,8 @@ func init() {
 
 		TaskChangedEvent: func(data interface{}) {
 			tm := data.(*model.TaskManager)
-			ui.Task.SetData(tm.Tasks)
-			if len(tm.Tasks) == 0 && ui.Task.showDetail {
+			ui.Task.SetData(tm.Tasks())
+			if len(tm.Tasks()) == 0 && ui.Task.showDetail {
 				ui.Task.Close()
 			}
 			Redraw(ui.headerRight)
@@ -357,15 +390,119 @@ func init() {
 
 		ShowHelpEvent: func(data interface{}) {
 			termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
-			if data.(bool) {
+			ui.showHelp = data.(bool)
+			if ui.showHelp {
 				ui.help.Draw()
 			} else {
 				redraw()
 			}
 		},
+
+		TogglePreviewEvent: func(data interface{}) {
+			if data.(bool) {
+				ui.showPreview = true
+				drawPreview()
+				return
+			}
+			clearPreview()
+			ui.showPreview = false
+		},
+
+		PreviewEvent: func(data interface{}) {
+			switch dd := data.(type) {
+			case *model.Preview:
+				ui.Preview.SetData(dd, nil)
+			case error:
+				ui.Preview.SetData(nil, dd)
+			}
+			drawPreview()
+		},
+
+		HexEvent: func(data interface{}) {
+			if data == nil {
+				ui.showHex = false
+				termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+				redraw()
+				return
+			}
+
+			if ui.showHex {
+				ui.hex.Clear()
+			} else {
+				ui.showHex = true
+				termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+				ui.Status.Clear()
+				ui.Status.Draw()
+			}
+			ui.hex.data = data.(*HexData)
+			ui.hex.Draw()
+		},
+
+		HistoryEvent: func(data interface{}) {
+			if data == nil {
+				ui.showHistory = false
+				termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+				redraw()
+				return
+			}
+
+			if ui.showHistory {
+				ui.history.Clear()
+			} else {
+				ui.showHistory = true
+				termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+				ui.Status.Clear()
+				ui.Status.Draw()
+			}
+			ui.history.data = data.(*HistoryData)
+			ui.history.Draw()
+		},
+
+		SumsEvent: func(data interface{}) {
+			if data == nil {
+				ui.showSums = false
+				termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+				redraw()
+				return
+			}
+
+			if ui.showSums {
+				ui.sums.Clear()
+			} else {
+				ui.showSums = true
+				termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+				ui.Status.Clear()
+				ui.Status.Draw()
+			}
+			ui.sums.data = data.(*SumsData)
+			ui.sums.Draw()
+		},
+
+		CompletionEvent: func(data interface{}) {
+			if data == nil {
+				ui.completion.Close()
+				return
+			}
+			ui.completion.Open(ui.Status.items[1].Start.X, data.(*CompletionData))
+		},
 	} {
 		handlers[k] = v
 	}
+
+	// these events may change the position of the preview
+	for _, k := range []EventType{
+		ChangeGroupEvent, ColumnContentChangeEvent, ToggleDetailEvent, OpenRightEvent,
+		CloseRightEvent, ToParentEvent, ShiftEvent, JumpToEvent, ColumnsRefreshEvent, ChangeRootEvent,
+		ToggleBookmarkEvent, BookmarkChangedEvent, ClipChangedEvent, ToggleClipDetailEvent,
+		TaskChangedEvent, ToggleTaskDetailEvent,
+	} {
+		h := handlers[k]
+		handlers[k] = func(data interface{}) {
+			clearPreview()
+			h(data)
+			drawPreview()
+		}
+	}
 }
 
 func startEventLoop() {
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/jacokoo/fff/model"
@@ -25,10 +26,19 @@ func newFileList(p *Point, height int) *FileList {
 }
 
 func (fl *FileList) setData(co model.Column) {
-	names, hints := fileNames(co)
-	fl.list.SetData(names, hints, co.Current())
+	if co.IsLoading() {
+		fl.list.SetData([]string{"  loading..."}, []int{0}, -1)
+		fl.setFilter("")
+		fl.countInfo.Data = "[...]"
+		return
+	}
+
+	fl.list.SetRows(len(co.Files()), fileRow(co), co.Current())
 	fl.setFilter(co.Filter())
 	fl.setCurrent(co.Current())
+	if co.IsPartial() {
+		fl.countInfo.Data = fmt.Sprintf("[%d/%d+]", co.Current()+1, fl.list.Count())
+	}
 }
 
 func (fl *FileList) setFilter(filter string) {
@@ -37,7 +47,7 @@ func (fl *FileList) setFilter(filter string) {
 
 func (fl *FileList) setCurrent(current int) {
 	fl.list.Select(current)
-	fl.countInfo.Data = fmt.Sprintf("[%d/%d]", current+1, len(fl.list.Data))
+	fl.countInfo.Data = fmt.Sprintf("[%d/%d]", current+1, fl.list.Count())
 }
 
 // Draw it
@@ -98,7 +108,10 @@ func formatSize(size int64) string {
 func expandedName(size string, maxSize int, fi model.FileItem) string {
 	ti := fi.ModTime().Format("2006-01-02 15:04:05")
 	md := fi.Mode().String()
-	si := strings.Repeat(" ", maxSize-len(size)) + size
+	si := size
+	if len(size) < maxSize {
+		si = strings.Repeat(" ", maxSize-len(size)) + size
+	}
 	return fmt.Sprintf("%s  %s  %s  %s ", ti, md, si, fi.Name())
 }
 
@@ -114,41 +127,55 @@ func normalName(size string, v model.FileItem) string {
 	return fmt.Sprintf("%s%s%s  ", na, strings.Repeat(" ", re), size)
 }
 
-func fileNames(co model.Column) ([]string, []int) {
-	le := len(co.Files())
-	names := make([]string, le)
-	hints := make([]int, le)
-	sis := make([]string, le)
+// sizeWidth the width of formatSize(size) without formatting it
+func sizeWidth(size int64) int {
+	if size <= 1024 {
+		return len(strconv.FormatInt(size, 10)) + 1
+	}
+
+	b := float64(size) / 1024
+	for i := 0; i < 2 && b > 1024; i++ {
+		b = b / 1024
+	}
+	return len(strconv.FormatInt(int64(b*100+0.5)/100, 10)) + 4
+}
+
+// fileRow format the ith file of co when it is drawn, only the visible files are formatted
+func fileRow(co model.Column) func(int) (string, int) {
+	files := co.Files()
+	detail := co.IsShowDetail()
+
+	marked := make(map[model.FileItem]bool)
+	for _, v := range co.Marked() {
+		marked[v] = true
+	}
 
 	maxSize := 0
-	for i, v := range co.Files() {
-		sis[i] = formatSize(v.Size())
-		if len(sis[i]) > maxSize {
-			maxSize = len(sis[i])
+	if detail {
+		for _, v := range files {
+			if w := sizeWidth(v.Size()); w > maxSize {
+				maxSize = w
+			}
 		}
 	}
 
-	for i, v := range co.Files() {
+	return func(i int) (string, int) {
+		v := files[i]
+		size := formatSize(v.Size())
 		var n string
-		if co.IsShowDetail() {
-			n = expandedName(sis[i], maxSize, v)
+		if detail {
+			n = expandedName(size, maxSize, v)
 		} else {
-			n = normalName(sis[i], v)
-		}
-
-		mark := " "
-		if co.IsMarked(i) {
-			mark = "*"
+			n = normalName(size, v)
 		}
 
-		names[i] = fmt.Sprintf(" %s%s", mark, n)
-		hints[i] = 0
+		mark, hint := " ", 0
 		if v.IsDir() {
-			hints[i] = 1
+			hint = 1
 		}
-		if co.IsMarked(i) {
-			hints[i] = 2
+		if marked[v] {
+			mark, hint = "*", 2
 		}
+		return fmt.Sprintf(" %s%s", mark, n), hint
 	}
-	return names, hints
 }
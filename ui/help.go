@@ -14,13 +14,13 @@ Navigation:
 		ss    sort current dir by size            sm    sort current dir by modify time
 		sn    sort current dir by name             g    refresh current dir
 		 d    toggle show file details             .    toggle show hidden files
-         ,    remove the first opened dir
+         ,    remove the first opened dir          p    toggle preview of selected item
 		 w    jump over all items displayed once   W    jump over all items displayed
 		 i    jump over the current dir once       I    jump over the current dir
 1, 2, 3, 4    switch to corresponding context
 		 ↵    open selected item use system default program
 			  ensure input (during input), cancel jump (during jump)
-	   esc    abort input (during input), cancel jump (during jump)
+	   esc    abort input (during input), cancel jump (during jump), cancel loading dir
 
 File:
          m    toggle mark file                     u    toggle mark all items
@@ -38,7 +38,7 @@ Bookmark:
 Misc:
  q, ctrl-q    Quit fff                             v    open selected file via pager
 		 !    start a shell in current dir         e    editor selected file
-		 ?    for help
+		 ?    for help                             x    view selected file in hex
 
 [Press any key to quit]`
 
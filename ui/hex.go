@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"fmt"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+// offset(8) + 2 spaces + 16 bytes + group space + 2 spaces + |ascii|
+const hexWideWidth = 8 + 2 + 16*3 + 1 + 2 + 18
+
+// HexData the bytes to show in hex view
+type HexData struct {
+	Name     string
+	Size     int64
+	Offset   int64
+	Data     []byte
+	Match    int64 // offset of the matched pattern, -1 for none
+	MatchLen int
+}
+
+// Hex a scrollable hex and ascii view of a file
+type Hex struct {
+	data *HexData
+	*Drawable
+}
+
+// NewHex create hex view
+func NewHex() *Hex {
+	return &Hex{nil, NewDrawable(ZeroPoint)}
+}
+
+// HexLayout the rows and bytes per row can be shown in hex view
+func HexLayout() (int, int) {
+	w, h := termbox.Size()
+	perRow := 8
+	if w >= hexWideWidth {
+		perRow = 16
+	}
+
+	// title, a blank line and the status bar
+	rows := h - 3
+	if rows < 1 {
+		rows = 1
+	}
+	return rows, perRow
+}
+
+func (h *Hex) matched(off int64) bool {
+	d := h.data
+	return d.Match != -1 && off >= d.Match && off < d.Match+int64(d.MatchLen)
+}
+
+func (h *Hex) drawRow(y int, off int64, bs []byte, perRow int) {
+	t := NewText(&Point{0, y}, fmt.Sprintf("%08x", off))
+	t.Color = colorKeyword()
+	t.Draw()
+
+	hx, ax := 10, 10+perRow*3+perRow/8+2
+	for i := 0; i < perRow; i++ {
+		x := hx + i*3 + i/8
+		if i >= len(bs) {
+			continue
+		}
+
+		c := colorNormal()
+		if h.matched(off + int64(i)) {
+			c = colorJump()
+		}
+		t = NewText(&Point{x, y}, fmt.Sprintf("%02x", bs[i]))
+		t.Color = c
+		t.Draw()
+
+		ch := '.'
+		if bs[i] >= 0x20 && bs[i] < 0x7f {
+			ch = rune(bs[i])
+		}
+		termbox.SetCell(ax+1+i, y, ch, c.FG, c.BG)
+	}
+
+	termbox.SetCell(ax, y, '|', termbox.ColorDefault, termbox.ColorDefault)
+	termbox.SetCell(ax+1+len(bs), y, '|', termbox.ColorDefault, termbox.ColorDefault)
+}
+
+// Draw it
+func (h *Hex) Draw() *Point {
+	w, hh := termbox.Size()
+	h.End = &Point{w - 1, hh - 2}
+	if h.data == nil {
+		return h.End
+	}
+
+	d := h.data
+	percent := int64(100)
+	if d.Size > 0 {
+		percent = (d.Offset + int64(len(d.Data))) * 100 / d.Size
+	}
+	title := NewText(h.Start, fmt.Sprintf("%s  [%s]  %08x / %08x  %d%%", d.Name, formatSize(d.Size), d.Offset, d.Size, percent))
+	title.Color = colorKeyword()
+	title.Draw()
+
+	rows, perRow := HexLayout()
+	for i := 0; i < rows; i++ {
+		from := i * perRow
+		if from >= len(d.Data) {
+			break
+		}
+		to := from + perRow
+		if to > len(d.Data) {
+			to = len(d.Data)
+		}
+		h.drawRow(h.Start.Y+2+i, d.Offset+int64(from), d.Data[from:to], perRow)
+	}
+	return h.End
+}
+
+// Clear it
+func (h *Hex) Clear() {
+	w, hh := termbox.Size()
+	h.End = &Point{w - 1, hh - 2}
+	h.Rect.Clear()
+}
@@ -0,0 +1,337 @@
+package ui
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// span a piece of a line with the same color
+type span struct {
+	text  string
+	color *Color
+}
+
+type spans []*span
+
+func (s *spans) add(text string, color *Color) {
+	if len(text) == 0 {
+		return
+	}
+	*s = append(*s, &span{text, color})
+}
+
+type hlState struct {
+	inBlock bool
+	inRaw   bool
+}
+
+// syntax a simple line based highlighter, it is good enough for a preview
+type syntax struct {
+	lineComment  string
+	hashComment  bool // # starts a comment at line start or after a space
+	blockComment [2]string
+	quotes       string // a string in these quotes ends in the same line
+	rawQuote     string // a string in this quote may span lines, without escape
+	keyString    bool   // a string followed by : is a key, for json
+	keyLine      *regexp.Regexp
+	variables    bool // $name, ${name}
+	wordChars    string
+	keywords     map[string]bool
+	types        map[string]bool
+}
+
+func words(s string) map[string]bool {
+	mp := make(map[string]bool)
+	for _, v := range strings.Fields(s) {
+		mp[v] = true
+	}
+	return mp
+}
+
+var (
+	syntaxGo = &syntax{
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		rawQuote:     "`",
+		keywords: words(`break case chan const continue default defer else fallthrough for func go goto if
+			import interface map package range return select struct switch type var true false nil iota`),
+		types: words(`bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64
+			rune string uint uint8 uint16 uint32 uint64 uintptr append cap close copy delete len make new panic print println recover`),
+	}
+
+	syntaxJSON = &syntax{
+		quotes:    `"`,
+		keyString: true,
+		keywords:  words("true false null"),
+	}
+
+	syntaxYAML = &syntax{
+		hashComment: true,
+		quotes:      `"'`,
+		keyLine:     regexp.MustCompile(`^(\s*(?:-\s+)?)([^\s#'"\-][^:#]*|"[^"]*"|'[^']*')(:)(\s|$)`),
+		wordChars:   "-.",
+		keywords:    words("true false yes no on off null ~ --- ..."),
+	}
+
+	syntaxShell = &syntax{
+		hashComment: true,
+		quotes:      `"'`,
+		variables:   true,
+		wordChars:   "-",
+		keywords: words(`if then else elif fi for while until do done case esac in function return
+			local export readonly declare unset shift break continue exit source alias set eval exec trap`),
+	}
+
+	syntaxExtensions = map[string]*syntax{
+		".go":   syntaxGo,
+		".json": syntaxJSON,
+		".yml":  syntaxYAML,
+		".yaml": syntaxYAML,
+		".sh":   syntaxShell,
+		".bash": syntaxShell,
+		".zsh":  syntaxShell,
+	}
+
+	shellNames     = words(".bashrc .bash_profile .profile .zshrc .zprofile")
+	markdownExts   = words(".md .markdown")
+	mdHeading      = regexp.MustCompile(`^#{1,6}\s`)
+	mdQuote        = regexp.MustCompile(`^\s*>`)
+	mdListMarker   = regexp.MustCompile(`^(\s*)([-*+]|\d+\.)(\s)`)
+	mdInline       = regexp.MustCompile("`[^`]*`|\\[[^\\]]*\\]\\([^)]*\\)")
+	mdFence        = regexp.MustCompile("^\\s*(```|~~~)")
+	shebangMatcher = regexp.MustCompile(`^#!.*\b(sh|bash|zsh)\b`)
+)
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func (sy *syntax) isWordChar(c byte) bool {
+	return isLetter(c) || isDigit(c) || strings.IndexByte(sy.wordChars, c) != -1
+}
+
+// closeQuote index after the closing quote, or len(s)
+func closeQuote(s string, i int, escape bool) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		if escape && s[j] == '\\' {
+			j++
+			continue
+		}
+		if s[j] == q {
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+func followedByColon(s string, i int) bool {
+	rest := strings.TrimLeft(s[i:], " \t")
+	return strings.HasPrefix(rest, ":")
+}
+
+func (sy *syntax) line(s string, st *hlState) spans {
+	re := make(spans, 0)
+	i := 0
+
+	if st.inBlock {
+		end := strings.Index(s, sy.blockComment[1])
+		if end == -1 {
+			re.add(s, colorCodeComment())
+			return re
+		}
+		i = end + len(sy.blockComment[1])
+		re.add(s[:i], colorCodeComment())
+		st.inBlock = false
+	}
+
+	if st.inRaw {
+		end := strings.Index(s, sy.rawQuote)
+		if end == -1 {
+			re.add(s, colorCodeString())
+			return re
+		}
+		i = end + len(sy.rawQuote)
+		re.add(s[:i], colorCodeString())
+		st.inRaw = false
+	}
+
+	if i == 0 && sy.keyLine != nil {
+		if m := sy.keyLine.FindStringSubmatchIndex(s); m != nil {
+			re.add(s[:m[3]], colorNormal())
+			re.add(s[m[4]:m[5]], colorCodeKey())
+			i = m[5]
+		}
+	}
+
+	plain := i
+	flush := func(to int) {
+		re.add(s[plain:to], colorNormal())
+	}
+
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case sy.lineComment != "" && strings.HasPrefix(s[i:], sy.lineComment),
+			sy.hashComment && c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			flush(i)
+			re.add(s[i:], colorCodeComment())
+			return re
+
+		case sy.blockComment[0] != "" && strings.HasPrefix(s[i:], sy.blockComment[0]):
+			flush(i)
+			end := strings.Index(s[i+len(sy.blockComment[0]):], sy.blockComment[1])
+			if end == -1 {
+				re.add(s[i:], colorCodeComment())
+				st.inBlock = true
+				return re
+			}
+			j := i + len(sy.blockComment[0]) + end + len(sy.blockComment[1])
+			re.add(s[i:j], colorCodeComment())
+			i, plain = j, j
+
+		case sy.rawQuote != "" && strings.HasPrefix(s[i:], sy.rawQuote):
+			flush(i)
+			end := strings.Index(s[i+len(sy.rawQuote):], sy.rawQuote)
+			if end == -1 {
+				re.add(s[i:], colorCodeString())
+				st.inRaw = true
+				return re
+			}
+			j := i + len(sy.rawQuote) + end + len(sy.rawQuote)
+			re.add(s[i:j], colorCodeString())
+			i, plain = j, j
+
+		case strings.IndexByte(sy.quotes, c) != -1:
+			flush(i)
+			j := closeQuote(s, i, !(sy.variables && c == '\''))
+			color := colorCodeString()
+			if sy.keyString && followedByColon(s, j) {
+				color = colorCodeKey()
+			}
+			re.add(s[i:j], color)
+			i, plain = j, j
+
+		case sy.variables && c == '$' && i+1 < len(s):
+			flush(i)
+			j := i + 1
+			if s[j] == '{' {
+				if end := strings.IndexByte(s[j:], '}'); end != -1 {
+					j += end + 1
+				} else {
+					j = len(s)
+				}
+			} else if isLetter(s[j]) {
+				for j < len(s) && (isLetter(s[j]) || isDigit(s[j])) {
+					j++
+				}
+			} else {
+				j++
+			}
+			re.add(s[i:j], colorCodeType())
+			i, plain = j, j
+
+		case isDigit(c) && (i == 0 || !sy.isWordChar(s[i-1])):
+			j := i
+			for j < len(s) && (sy.isWordChar(s[j]) || s[j] == '.') {
+				j++
+			}
+			flush(i)
+			re.add(s[i:j], colorCodeNumber())
+			i, plain = j, j
+
+		case isLetter(c) || (c == '-' && sy.keywords["---"]):
+			j := i
+			for j < len(s) && sy.isWordChar(s[j]) {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			w := s[i:j]
+			switch {
+			case sy.keywords[w]:
+				flush(i)
+				re.add(w, colorCodeKeyword())
+				plain = j
+			case sy.types[w]:
+				flush(i)
+				re.add(w, colorCodeType())
+				plain = j
+			}
+			i = j
+
+		default:
+			i++
+		}
+	}
+	flush(len(s))
+	return re
+}
+
+func markdownInline(s string, re *spans) {
+	last := 0
+	for _, m := range mdInline.FindAllStringIndex(s, -1) {
+		re.add(s[last:m[0]], colorNormal())
+		if s[m[0]] == '`' {
+			re.add(s[m[0]:m[1]], colorCodeString())
+		} else {
+			re.add(s[m[0]:m[1]], colorCodeType())
+		}
+		last = m[1]
+	}
+	re.add(s[last:], colorNormal())
+}
+
+func markdownLine(s string, st *hlState) spans {
+	re := make(spans, 0)
+	if mdFence.MatchString(s) {
+		st.inRaw = !st.inRaw
+		re.add(s, colorCodeComment())
+		return re
+	}
+
+	switch {
+	case st.inRaw:
+		re.add(s, colorCodeString())
+	case mdHeading.MatchString(s):
+		re.add(s, colorCodeKeyword())
+	case mdQuote.MatchString(s):
+		re.add(s, colorCodeComment())
+	default:
+		if m := mdListMarker.FindStringSubmatchIndex(s); m != nil {
+			re.add(s[:m[4]], colorNormal())
+			re.add(s[m[4]:m[5]], colorCodeNumber())
+			s = s[m[5]:]
+		}
+		markdownInline(s, &re)
+	}
+	return re
+}
+
+// highlight lines of the file by name, nil if the type is unknown
+func highlight(name string, lines []string) []spans {
+	ext := strings.ToLower(filepath.Ext(name))
+	var fn func(string, *hlState) spans
+	if sy, ok := syntaxExtensions[ext]; ok {
+		fn = sy.line
+	} else if markdownExts[ext] {
+		fn = markdownLine
+	} else if shellNames[name] || (len(lines) > 0 && shebangMatcher.MatchString(lines[0])) {
+		fn = syntaxShell.line
+	}
+
+	if fn == nil {
+		return nil
+	}
+
+	st := new(hlState)
+	re := make([]spans, len(lines))
+	for i, v := range lines {
+		re[i] = fn(v, st)
+	}
+	return re
+}
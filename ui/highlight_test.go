@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+var codeColors = []string{"normal", "code-keyword", "code-string", "code-comment", "code-number", "code-type", "code-key"}
+
+// markSpans write the spans of line as text, a colored span is written as <color:text>
+func markSpans(ss spans) string {
+	names := make(map[*Color]string)
+	for k, v := range colors {
+		names[v] = strings.TrimPrefix(k, "code-")
+	}
+
+	re := ""
+	for _, v := range ss {
+		if name := names[v.color]; name != "normal" {
+			re += "<" + name + ":" + v.text + ">"
+		} else {
+			re += v.text
+		}
+	}
+	return re
+}
+
+func markLines(name string, lines ...string) []string {
+	ss := highlight(name, lines)
+	if ss == nil {
+		return nil
+	}
+	re := make([]string, len(ss))
+	for i, v := range ss {
+		re[i] = markSpans(v)
+	}
+	return re
+}
+
+func TestHighlight(t *testing.T) {
+	cs := make(map[string]*Color)
+	for i, v := range codeColors {
+		cs[v] = &Color{termbox.Attribute(i + 1), termbox.ColorDefault}
+	}
+	old := colors
+	SetColors(cs)
+	defer SetColors(old)
+
+	cases := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{"a.go", []string{"func f() int { // 1", "return 10 /* a", "b */ + `x", "y`"}, []string{
+			"<keyword:func> f() <type:int> { <comment:// 1>",
+			"<keyword:return> <number:10> <comment:/* a>",
+			"<comment:b */> + <string:`x>",
+			"<string:y`>",
+		}},
+		{"a.json", []string{`{"a": "b", "c": true}`}, []string{
+			`{<key:"a">: <string:"b">, <key:"c">: <keyword:true>}`,
+		}},
+		{"a.yml", []string{"- name: 'x' # y", "  on: yes"}, []string{
+			"- <key:name>: <string:'x'> <comment:# y>",
+			"  <key:on>: <keyword:yes>",
+		}},
+		{"run", []string{"#!/usr/bin/env bash", `echo "$A" '$B' ${C}x $1`}, []string{
+			"<comment:#!/usr/bin/env bash>",
+			`echo <string:"$A"> <string:'$B'> <type:${C}>x <type:$1>`,
+		}},
+		{".bashrc", []string{"export A=1"}, []string{"<keyword:export> A=<number:1>"}},
+		{"a.md", []string{"# T", "- `c` [l](u)", "```", "# x", "```"}, []string{
+			"<keyword:# T>",
+			"<number:-> <string:`c`> <type:[l](u)>",
+			"<comment:```>",
+			"<string:# x>",
+			"<comment:```>",
+		}},
+	}
+
+	for _, c := range cases {
+		got := markLines(c.name, c.lines...)
+		if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+			t.Errorf("%s: expect\n%s\ngot\n%s", c.name, strings.Join(c.want, "\n"), strings.Join(got, "\n"))
+		}
+	}
+
+	if got := markLines("a.txt", "func main"); got != nil {
+		t.Errorf("a.txt should not be highlighted, got %q", got)
+	}
+}
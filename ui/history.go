@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// HistoryData the finished tasks to show in history view, the latest first
+type HistoryData struct {
+	Records  []*model.TaskRecord
+	Selected int
+}
+
+// History a list of the finished tasks, the errors are listed under each task
+type History struct {
+	data *HistoryData
+	list *List
+	*Drawable
+}
+
+// NewHistory create history view
+func NewHistory() *History {
+	_, h := termbox.Size()
+	// title, a blank line and the status bar
+	return &History{nil, NewList(ZeroPoint.DownN(2), 0, h-3, nil, nil), NewDrawable(ZeroPoint)}
+}
+
+func recordLine(r *model.TaskRecord) string {
+	s := fmt.Sprintf("%s  %-10s %-9s  %s", r.Started().Format("2006-01-02 15:04:05"), r.Task().Name(), r.Status(), r.Duration().Round(time.Millisecond))
+	if b := r.Bytes(); b >= 0 {
+		s = fmt.Sprintf("%s  %s", s, formatSize(b))
+	}
+	if n := len(r.Errors()); n > 0 {
+		s = fmt.Sprintf("%s  %d errors", s, n)
+	}
+	return s
+}
+
+// outputLines the lines of a command output shown under its record
+const outputLines = 10
+
+var outputReplacer = strings.NewReplacer("\t", "    ", "\r", "")
+
+// outputTail the last lines of output, the tabs are expanded since the list draws a rune per cell
+func outputTail(output string) []string {
+	output = strings.TrimRight(output, "\r\n")
+	if output == "" {
+		return nil
+	}
+	lines := strings.Split(outputReplacer.Replace(output), "\n")
+	if len(lines) > outputLines {
+		lines = lines[len(lines)-outputLines:]
+	}
+	return lines
+}
+
+// rows of the list, and the row of the selected record
+func (h *History) rows() ([]string, []int, int) {
+	ns, hs, selected := make([]string, 0), make([]int, 0), 0
+	for i, v := range h.data.Records {
+		if i == h.data.Selected {
+			selected = len(ns)
+		}
+
+		hint := 1
+		switch v.Status() {
+		case model.TaskFailed:
+			hint = 2
+		case model.TaskCanceled:
+			hint = 0
+		}
+		ns, hs = append(ns, recordLine(v)), append(hs, hint)
+		for _, e := range v.Errors() {
+			ns, hs = append(ns, fmt.Sprintf("    %s: %s", e.Task.Name(), e.Err)), append(hs, 0)
+		}
+		if ct, ok := v.Task().(*model.CommandTask); ok {
+			for _, line := range outputTail(ct.Output()) {
+				ns, hs = append(ns, "    "+line), append(hs, 0)
+			}
+		}
+	}
+	return ns, hs, selected
+}
+
+// Draw it
+func (h *History) Draw() *Point {
+	w, hh := termbox.Size()
+	h.End = &Point{w - 1, hh - 2}
+	if h.data == nil {
+		return h.End
+	}
+
+	title := NewText(h.Start, fmt.Sprintf("Task history  [%d/%d]", h.data.Selected+1, len(h.data.Records)))
+	title.Color = colorKeyword()
+	title.Draw()
+
+	ns, hs, selected := h.rows()
+	h.list.Height = hh - 3
+	h.list.SetData(ns, hs, selected)
+	h.list.Draw()
+	return h.End
+}
+
+// Clear it
+func (h *History) Clear() {
+	w, hh := termbox.Size()
+	h.End = &Point{w - 1, hh - 2}
+	h.Rect.Clear()
+}
@@ -100,7 +100,7 @@ func (p *Path) JumpItems(fn func(string) func() bool) []*JumpItem {
 func (fl *List) JumpItems(namefn func(int) string, fn func(int) func() bool) []*JumpItem {
 	re := make([]*JumpItem, 0)
 	for i := fl.from; i < fl.to; i++ {
-		it := fl.items[i]
+		it := fl.items[i-fl.from]
 		ac := fn(i)
 		if ac == nil {
 			continue
@@ -3,34 +3,33 @@ package ui
 const minWidth = 10
 
 // List a list of string
+// the rows are created only for the visible window, so a huge list is cheap to draw
 type List struct {
-	Selected   int
-	Height     int
-	Data       []string
-	colors     []*Color
-	items      []*Text
-	colorHints []int
-	from, to   int
+	Selected int
+	Height   int
+	Data     []string
+	colors   []*Color
+	items    []*Text
+	count    int
+	row      func(int) (string, int)
+	from, to int
 	*Drawable
 }
 
 // NewList create a list
 func NewList(p *Point, selected, height int, items []string, colorHints []int) *List {
-	is := make([]*Text, len(items))
-	for i, v := range items {
-		is[i] = NewText(p.DownN(i), v)
-	}
-
 	cs := []*Color{colorFile(), colorFolder(), colorMarked()}
-	return &List{selected, height, items, cs, is, colorHints, 0, 0, NewDrawable(p)}
+	l := &List{selected, height, nil, cs, nil, 0, nil, 0, 0, NewDrawable(p)}
+	l.SetData(items, colorHints, selected)
+	return l
 }
 
 // Draw it
 func (l *List) Draw() *Point {
 	var maxX = l.Start.X + minWidth
 	from, to := 0, l.Height
-	if to > len(l.items) {
-		to = len(l.items)
+	if to > l.count {
+		to = l.count
 	} else {
 		delta := l.Selected - l.Height/2
 		if delta > 0 {
@@ -38,8 +37,8 @@ func (l *List) Draw() *Point {
 			from += delta
 		}
 
-		if to > len(l.items) {
-			delta = to - len(l.items)
+		if to > l.count {
+			delta = to - l.count
 			to -= delta
 			from -= delta
 		}
@@ -47,18 +46,19 @@ func (l *List) Draw() *Point {
 
 	l.from = from
 	l.to = to
-	j := 0
+	l.items = make([]*Text, 0, to-from)
 	for i := from; i < to; i++ {
-		v := l.items[i]
-		v.Color = l.colors[l.colorHints[i]]
+		str, hint := l.row(i)
+		v := NewText(ZeroPoint, str)
+		v.Color = l.colors[hint]
 		if i == l.Selected {
 			v.Color = v.Color.Reverse()
 		}
-		p := Move(v, l.Start.DownN(j))
+		p := Move(v, l.Start.DownN(i-from))
 		if p.X > maxX {
 			maxX = p.X
 		}
-		j++
+		l.items = append(l.items, v)
 	}
 
 	l.End.X = maxX
@@ -68,24 +68,31 @@ func (l *List) Draw() *Point {
 
 // Select change the selected item to item
 func (l *List) Select(item int) {
-	if len(l.items) == 0 {
+	if l.count == 0 {
 		return
 	}
-	old := l.items[l.Selected]
-	old.Color = l.colors[l.colorHints[l.Selected]]
 	l.Selected = item
 }
 
+// Count of the items
+func (l *List) Count() int {
+	return l.count
+}
+
 // SetData update items
 func (l *List) SetData(items []string, hints []int, selected int) {
-	l.Selected = selected
 	l.Data = items
-	l.colorHints = hints
+	l.SetRows(len(items), func(i int) (string, int) {
+		return items[i], hints[i]
+	}, selected)
+}
+
+// SetRows update items, row returns the text and the color hint of the ith item
+func (l *List) SetRows(count int, row func(int) (string, int), selected int) {
+	l.Selected = selected
+	l.count = count
+	l.row = row
+	l.items = nil
 	l.from = 0
 	l.to = 0
-
-	l.items = make([]*Text, len(items))
-	for i, v := range items {
-		l.items[i] = NewText(l.Start.DownN(i), v)
-	}
 }
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/jacokoo/fff/model"
+	runewidth "github.com/mattn/go-runewidth"
+	termbox "github.com/nsf/termbox-go"
+)
+
+const minPreviewWidth = 20
+
+// Preview show the content of the selected item
+type Preview struct {
+	Width, Height int
+	title         *Text
+	lines         []spans
+	*Drawable
+}
+
+// NewPreview create preview
+func NewPreview(p *Point, width, height int) *Preview {
+	title := NewText(p, "")
+	title.Color = colorKeyword()
+	return &Preview{width, height, title, nil, NewDrawable(p)}
+}
+
+func cutWidth(str string, width int) (string, int) {
+	s, c := "", 0
+	for _, v := range str {
+		w := runewidth.RuneWidth(v)
+		if c+w > width {
+			break
+		}
+		s += string(v)
+		c += w
+	}
+	return s, c
+}
+
+func plainLines(lines []string) []spans {
+	re := make([]spans, len(lines))
+	for i, v := range lines {
+		re[i] = spans{&span{v, colorNormal()}}
+	}
+	return re
+}
+
+// SetData update content
+func (p *Preview) SetData(pv *model.Preview, err error) {
+	p.lines = nil
+	if err != nil {
+		p.title.Data = err.Error()
+		return
+	}
+
+	p.title.Data = pv.Item.Name()
+	switch pv.Type {
+	case model.PreviewDir:
+		p.title.Data = fmt.Sprintf("%s/  [%d items]", pv.Item.Name(), len(pv.Items))
+		for _, v := range pv.Items {
+			c := colorFile()
+			if v.IsDir() {
+				c = colorFolder()
+			}
+			p.lines = append(p.lines, spans{&span{v.Name(), c}})
+		}
+		return
+	case model.PreviewArchive:
+		p.title.Data = fmt.Sprintf("%s  [archive]", pv.Item.Name())
+	case model.PreviewBinary:
+		p.title.Data = fmt.Sprintf("%s  [binary %s]", pv.Item.Name(), formatSize(pv.Item.Size()))
+	case model.PreviewText:
+		if pv.Encoding != model.EncodingUTF8 {
+			p.title.Data = fmt.Sprintf("%s  [%s]", pv.Item.Name(), pv.Encoding)
+		}
+		if hl := highlight(pv.Item.Name(), pv.Lines); hl != nil {
+			p.lines = hl
+			return
+		}
+	}
+
+	p.lines = plainLines(pv.Lines)
+}
+
+func (p *Preview) drawLine(pt *Point, line spans) {
+	width := p.Width
+	t := NewText(pt, "")
+	for _, v := range line {
+		if width <= 0 {
+			return
+		}
+		s, w := cutWidth(v.text, width)
+		if w == 0 {
+			continue
+		}
+		t.Data = s
+		t.Color = v.color
+		pt = Move(t, pt).Right()
+		width -= w
+	}
+}
+
+// Draw it
+func (p *Preview) Draw() *Point {
+	p.End = &Point{p.Start.X + p.Width - 1, p.Start.Y + p.Height - 1}
+	if p.Width < minPreviewWidth {
+		return p.End
+	}
+
+	title := p.title.Data
+	p.title.Data, _ = cutWidth(title, p.Width)
+	Move(p.title, p.Start)
+	p.title.Data = title
+
+	for i, v := range p.lines {
+		if i+2 >= p.Height {
+			break
+		}
+		p.drawLine(p.Start.DownN(i+2), v)
+	}
+	return p.End
+}
+
+// Clear it
+func (p *Preview) Clear() {
+	w, _ := termbox.Size()
+	p.End = &Point{w - 1, p.Start.Y + p.Height - 1}
+	p.Rect.Clear()
+}
+
+func (p *Preview) layout() bool {
+	w, _ := termbox.Size()
+	last := ui.Column.Last()
+	p.Start = &Point{last.End.X + 2, ui.Column.Start.Y + 1}
+	p.Width = w - p.Start.X - 1
+	p.Height = ui.Column.Height - 1
+	return p.Width >= minPreviewWidth
+}
+
+func previewVisible() bool {
+	return ui.showPreview && !ui.showHelp && !ui.showHex && !ui.showHistory && !ui.showSums && !ui.Task.showDetail && !ui.Clip.showDetail
+}
+
+func drawPreview() {
+	if !previewVisible() {
+		return
+	}
+
+	if ui.Preview.layout() {
+		ui.Preview.Clear()
+		ui.Preview.Draw()
+	}
+}
+
+func clearPreview() {
+	if previewVisible() {
+		ui.Preview.Clear()
+	}
+}
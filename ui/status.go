@@ -10,6 +10,15 @@ type StatusItem struct {
 	*Text
 }
 
+// InputData the line of input, Cursor and the selection [SelFrom, SelTo) are rune indexes of Value
+type InputData struct {
+	Name    string
+	Value   string
+	Cursor  int
+	SelFrom int
+	SelTo   int
+}
+
 // Status bar
 type Status struct {
 	items []*StatusItem
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// SumsData the checksums of files to show in checksum view
+type SumsData struct {
+	Dir      string
+	Sums     []model.Sum
+	Selected int
+}
+
+// Sums a list of checksums in the format of sha256sum, the failed files are listed with their errors
+type Sums struct {
+	data *SumsData
+	list *List
+	*Drawable
+}
+
+// NewSums create checksum view
+func NewSums() *Sums {
+	_, h := termbox.Size()
+	// title, a blank line and the status bar
+	return &Sums{nil, NewList(ZeroPoint.DownN(2), 0, h-3, nil, nil), NewDrawable(ZeroPoint)}
+}
+
+// Draw it
+func (s *Sums) Draw() *Point {
+	w, h := termbox.Size()
+	s.End = &Point{w - 1, h - 2}
+	if s.data == nil {
+		return s.End
+	}
+
+	failed := 0
+	ns, hs := make([]string, len(s.data.Sums)), make([]int, len(s.data.Sums))
+	for i, v := range s.data.Sums {
+		switch {
+		case v.Err != nil:
+			failed++
+			ns[i], hs[i] = fmt.Sprintf("%-64s  %s: %s", "FAILED", v.Name, v.Err), 2
+		case v.Hash == "":
+			ns[i] = fmt.Sprintf("%-64s  %s", "canceled", v.Name)
+		default:
+			ns[i] = fmt.Sprintf("%s  %s", v.Hash, v.Name)
+		}
+	}
+
+	title := fmt.Sprintf("SHA256 of %d files in %s", len(ns), s.data.Dir)
+	if failed > 0 {
+		title = fmt.Sprintf("%s, %d failed", title, failed)
+	}
+	t := NewText(s.Start, title)
+	t.Color = colorKeyword()
+	t.Draw()
+
+	s.list.Height = h - 3
+	s.list.SetData(ns, hs, s.data.Selected)
+	s.list.Draw()
+	return s.End
+}
+
+// Clear it
+func (s *Sums) Clear() {
+	w, h := termbox.Size()
+	s.End = &Point{w - 1, h - 2}
+	s.Rect.Clear()
+}
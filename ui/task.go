@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jacokoo/fff/model"
 )
@@ -10,30 +11,60 @@ const (
 	taskDetailWidth = 60
 )
 
-// TaskItem render a task
+// transferInfo the bytes, rate and ETA of a transfer, empty if the task does not transfer bytes
+func transferInfo(t model.Task) string {
+	m := t.Meter()
+	if m == nil {
+		return ""
+	}
+
+	rate, eta := "--", "--"
+	if r := m.Rate(); r > 0 && !t.IsPaused() {
+		rate = formatSize(int64(r)) + "/s"
+	}
+	if d := m.ETA(); d >= 0 && !t.IsPaused() {
+		eta = d.Round(time.Second).String()
+	}
+	info := fmt.Sprintf("%s / %s  %s  ETA %s", formatSize(m.Done()), formatSize(m.Total()), rate, eta)
+	if l := t.RateLimit(); l > 0 {
+		info = fmt.Sprintf("%s  (limit %s/s)", info, formatSize(l))
+	}
+	return info
+}
+
+// TaskItem render a task, the bytes of a transfer are shown under the progress bar
 type TaskItem struct {
 	name *Text
 	pb   *ProgressBar
+	info *Text
 	*Drawable
 }
 
 // NewTaskItem create task item
 func NewTaskItem(p *Point, name string, width int) *TaskItem {
 	pb := NewProgressBar(p, width, 0)
-	return &TaskItem{NewText(p, name), pb, NewDrawable(p)}
+	return &TaskItem{NewText(p, name), pb, NewText(p, ""), NewDrawable(p)}
 }
 
 // Draw it
 func (ti *TaskItem) Draw() *Point {
 	Move(ti.name, ti.Start)
 	ti.End = Move(ti.pb, ti.Start.Down())
+	if ti.info.Data != "" {
+		p := Move(ti.info, ti.Start.DownN(2))
+		if p.X < ti.End.X {
+			p.X = ti.End.X
+		}
+		ti.End = p
+	}
 	return ti.End
 }
 
 // SetData update the progress
-func (ti *TaskItem) SetData(name string, progress int) {
+func (ti *TaskItem) SetData(name string, progress int, info string) {
 	ti.name.Data = name
 	ti.pb.Progress = progress
+	ti.info.Data = info
 }
 
 // BatchTaskItem render a batch task
@@ -51,16 +82,15 @@ func NewBatchTaskItem(p *Point, max, width int) *BatchTaskItem {
 
 // Draw it
 func (bt *BatchTaskItem) Draw() *Point {
-	p := Move(bt.task, bt.Start)
-	Move(bt.progress, p.Up().MoveLeftN(len(bt.progress.Data)-1))
-	bt.End = p
+	bt.End = Move(bt.task, bt.Start)
+	Move(bt.progress, bt.Start.RightN(bt.task.pb.Width).MoveLeftN(len(bt.progress.Data)-1))
 	return bt.End
 }
 
-// SetData update state
-func (bt *BatchTaskItem) SetData(name string, current int, subname string, subprogress int) {
+// SetData update state, progress is of the current task, or of all bytes if it is a transfer
+func (bt *BatchTaskItem) SetData(name string, current int, subname string, progress int, info string) {
 	bt.progress.Data = fmt.Sprintf("[%d/%d]", current, bt.max)
-	bt.task.SetData(fmt.Sprintf("%s / %s", name, subname), subprogress)
+	bt.task.SetData(fmt.Sprintf("%s / %s", name, subname), progress, info)
 }
 
 type pool struct {
@@ -139,19 +169,33 @@ func (t *Task) SetData(ts []model.Task) {
 	ss := make([]Drawer, len(ts))
 	n := ""
 	for i, v := range ts {
+		paused := ""
+		if v.IsPaused() {
+			paused = " (paused)"
+		}
+
 		switch vv := v.(type) {
 		case model.BatchTask:
 			bb := t.pool.getBatchTask()
 			bb.max = vv.Count()
 			ct := vv.CurrentTask()
-			bb.SetData(vv.Name(), vv.Current()+1, ct.Name(), ct.Current())
+			progress := ct.Current()
+			if m := vv.Meter(); m != nil {
+				progress = m.Percent()
+			}
+			bb.SetData(vv.Name(), vv.Current()+1, ct.Name()+paused, progress, transferInfo(vv))
 			ss[i] = bb
 		case model.Task:
 			bb := t.pool.getTask()
-			bb.SetData(vv.Name(), vv.Current())
+			bb.SetData(vv.Name()+paused, vv.Current(), transferInfo(vv))
 			ss[i] = bb
 		}
-		n = fmt.Sprintf("%s[%s %d/%d]", n, v.Name(), v.Current()+1, v.Count())
+
+		if m := v.Meter(); m != nil {
+			n = fmt.Sprintf("%s[%s %d/%d %d%%%s]", n, v.Name(), v.Current()+1, v.Count(), m.Percent(), paused)
+			continue
+		}
+		n = fmt.Sprintf("%s[%s %d/%d%s]", n, v.Name(), v.Current()+1, v.Count(), paused)
 	}
 
 	t.Data = n
@@ -40,8 +40,20 @@ type UI struct {
 	StatusMessage *StatusBackup
 	StatusInput   *StatusBackup
 
+	Preview     *Preview
+	showPreview bool
+
 	jumpItems []*FloatText
 	help      *List
+	showHelp  bool
+	hex       *Hex
+	showHex   bool
+
+	history     *History
+	showHistory bool
+	sums        *Sums
+	showSums    bool
+	completion  *Completion
 }
 
 func (ui *UI) isShowBookmark() bool {
@@ -119,6 +131,18 @@ func createUI(wo *model.Workspace) {
 	setFileInfo(wo.CurrentGroup().Current())
 
 	ui.help = NewHelp(h)
+	ui.showHelp = false
+	ui.hex = NewHex()
+	ui.showHex = false
+	ui.history = NewHistory()
+	ui.showHistory = false
+	ui.sums = NewSums()
+	ui.showSums = false
+	ui.completion = NewCompletion()
+
+	ui.Preview = NewPreview(ZeroPoint, 0, 0)
+	ui.showPreview = wo.IsShowPreview()
+	drawPreview()
 }
 
 // BookmarkList for jump bookmark
@@ -142,6 +166,7 @@ func redraw() {
 	ui.Task.Draw()
 	ui.Column.Draw()
 	ui.StatusMessage.Restore().Set(0, "")
+	drawPreview()
 }
 
 // Redraw redraw ui
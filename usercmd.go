@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+// the modes of user commands
+const (
+	// runForeground the command runs in the terminal like ActionShell, the ui is back when it exits
+	runForeground = "foreground"
+
+	// runBackground the command runs as a task, its output is kept in the task history
+	runBackground = "background"
+
+	// runSilent the command runs in background and its output is dropped, only a failure is shown
+	runSilent = "silent"
+)
+
+// userCommand a command of the commands section of config, run is a shell script with placeholders
+// wait asks for enter before the ui is back, it is for the foreground commands only
+type userCommand struct {
+	run  string
+	mode string
+	key  string
+	wait bool
+}
+
+var placeholder = regexp.MustCompile(`\{(file|dir|marked|clip|tabs)\}`)
+
+// shellQuote quote str by single quotes so that it is a single word of shell
+func shellQuote(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
+
+func quotePaths(items []model.FileItem) string {
+	ps := make([]string, len(items))
+	for i, v := range items {
+		ps[i] = shellQuote(v.Path())
+	}
+	return strings.Join(ps, " ")
+}
+
+// expandCommand replace the placeholders in script by the quoted paths
+// {file} the selected file, {dir} the current dir, {marked} the marked or selected files,
+// {clip} the clipped files and {tabs} the paths of the opened tabs
+func expandCommand(script string) (string, error) {
+	var err error
+	co := wo.CurrentGroup().Current()
+	re := placeholder.ReplaceAllStringFunc(script, func(p string) string {
+		switch p {
+		case "{file}":
+			file, e := co.CurrentFile()
+			if e != nil {
+				err = errors.New("no file selected")
+				return ""
+			}
+			return shellQuote(file.Path())
+		case "{dir}":
+			return shellQuote(co.Path())
+		case "{marked}":
+			items := co.MarkedOrSelected()
+			if len(items) == 0 {
+				err = errors.New("no file marked or selected")
+			}
+			return quotePaths(items)
+		case "{clip}":
+			if len(wo.Clip) == 0 {
+				err = errors.New("no clipped files")
+			}
+			return quotePaths(wo.Clip)
+		default:
+			ps := make([]string, 0, len(wo.Groups))
+			for _, v := range wo.Groups {
+				if v != nil {
+					ps = append(ps, shellQuote(v.Path()))
+				}
+			}
+			return strings.Join(ps, " ")
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return re, nil
+}
+
+// runUserCommand run the user command name, the command runs in the current dir if it is a local dir
+func runUserCommand(name string) error {
+	uc, ok := cfg.userCommands[name]
+	if !ok {
+		return fmt.Errorf("unknown command: %s", name)
+	}
+	script, err := expandCommand(uc.run)
+	if err != nil {
+		return err
+	}
+
+	dir := ""
+	cur := wo.CurrentGroup().Current().Path()
+	if pis, _ := model.ParsePath(cur); len(pis) == 1 {
+		dir = cur
+	}
+
+	return runScript(name, dir, script, uc.mode, uc.wait)
+}
+
+// runScript run script in dir by the mode of user commands, the foreground script runs after the ui is closed
+func runScript(name, dir, script, mode string, wait bool) error {
+	switch mode {
+	case runBackground:
+		task := model.NewCommandTask(name, dir, script)
+		task.Attach(model.NewListener(nil, func() {
+			if task.Succeeded() {
+				msg := name + " is done"
+				if line := task.LastLine(); line != "" {
+					msg = fmt.Sprintf("%s: %s", name, line)
+				}
+				ui.MessageEvent.Send(msg)
+			}
+		}))
+		submitTask(task)
+	case runSilent:
+		cm := cfg.cmd(script)
+		cm.Dir = dir
+		if err := cm.Start(); err != nil {
+			return err
+		}
+		startJob(func() {
+			if err := cm.Wait(); err != nil {
+				ui.MessageEvent.Send(fmt.Sprintf("%s: %s", name, err.Error()))
+			}
+		})
+	default:
+		cm := cfg.cmd(script)
+		delay = func() error {
+			cm.Dir = dir
+			cm.Stdin, cm.Stdout, cm.Stderr = os.Stdin, os.Stdout, os.Stderr
+			err := cm.Run()
+			if wait {
+				fmt.Print("\nPress enter to continue")
+				bufio.NewReader(os.Stdin).ReadString('\n')
+			}
+			return err
+		}
+		stopUI(2)
+	}
+	return nil
+}
+
+func cmdRun(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	return runUserCommand(args[0])
+}
+
+func runWords(i int) []string {
+	if i != 0 {
+		return nil
+	}
+	re := make([]string, 0, len(cfg.userCommands))
+	for k := range cfg.userCommands {
+		re = append(re, k)
+	}
+	sort.Strings(re)
+	return re
+}
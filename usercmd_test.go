@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jacokoo/fff/model"
+	termbox "github.com/nsf/termbox-go"
+)
+
+func TestLoopUserCommand(t *testing.T) {
+	tmp := tempDir(t, "it's", "b c")
+	defer os.RemoveAll(tmp)
+	model.SetDefault("sh", "less", "vi")
+	startLoop(tmp)
+
+	post(func() {
+		cfg.userCommands["list"] = &userCommand{"printf '%s\\n' {marked} > out; echo listed", runBackground, "", false}
+		co := wo.CurrentGroup().Current()
+		co.SelectByName("it's")
+		if _, err := expandCommand("cat {clip}"); err == nil {
+			t.Error("expect an error for no clipped files")
+		}
+		s, err := expandCommand("{file} {dir} {tabs}")
+		if q := shellQuote(filepath.Join(tmp, "it's")); err != nil || s != q+" "+shellQuote(tmp)+" "+shellQuote(tmp) {
+			t.Errorf("unexpected expansion: %s %v", s, err)
+		}
+		ac.toggleMarkAll()
+	})
+	waitLoop()
+
+	finished := make(chan bool, 1)
+	wo.Tm.Attach(model.NewTaskListener(nil, func(task model.Task) { finished <- true }, nil))
+	sendKeys(":run list")
+	sendKey(termbox.KeyEnter)
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command is not finished")
+	}
+
+	bs, _ := ioutil.ReadFile(filepath.Join(tmp, "out"))
+	if string(bs) != filepath.Join(tmp, "b c")+"\n"+filepath.Join(tmp, "it's")+"\n" {
+		t.Errorf("unexpected output %q", bs)
+	}
+	if r := wo.Tm.History()[0]; r.Task().(*model.CommandTask).LastLine() != "listed" {
+		t.Errorf("expect the output kept, got %q", r.Task().(*model.CommandTask).Output())
+	}
+}
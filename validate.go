@@ -0,0 +1,493 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jacokoo/fff/model"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// configProblem a problem of config found by checkConfig, line is 0 if it is not known
+// the config is still used with an error, the wrong setting is left out
+type configProblem struct {
+	line  int
+	fatal bool
+	msg   string
+}
+
+func (p *configProblem) format(name string) string {
+	level := "warning"
+	if p.fatal {
+		level = "error"
+	}
+	if p.line == 0 {
+		return fmt.Sprintf("%s: %s: %s", name, level, p.msg)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", name, p.line, level, p.msg)
+}
+
+// yamlIndex the lines of the keys in a block style yaml, the key b in the map of a is at lines["a/b"]
+// the items of a list are numbered, such as openers/0/ext. the keys defined more than once are in dups
+type yamlIndex struct {
+	lines map[string]int
+	dups  []yamlKey
+}
+
+type yamlKey struct {
+	path string
+	line int
+}
+
+type yamlFrame struct {
+	indent int
+	path   string
+	item   bool
+}
+
+// line the line of the key at path, 0 if it is not found
+func (yi *yamlIndex) line(path ...string) int {
+	return yi.lines[strings.Join(path, "/")]
+}
+
+// stripComment remove the comment of a yaml line, a # in quotes or after a non space is not a comment
+func stripComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote == '"' && ch == '\\':
+			i++
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+var plainKey = regexp.MustCompile(`^([^:]+?)\s*:(\s|$)`)
+
+// yamlKeyOf the key of a line such as key: value, "key": value or 'key':
+func yamlKeyOf(text string) (string, bool) {
+	if text == "" {
+		return "", false
+	}
+	if q := text[0]; q == '"' || q == '\'' {
+		for i := 1; i < len(text); i++ {
+			if q == '"' && text[i] == '\\' {
+				i++
+				continue
+			}
+			if text[i] != q {
+				continue
+			}
+			rest := strings.TrimLeft(text[i+1:], " ")
+			if !strings.HasPrefix(rest, ":") || (len(rest) > 1 && rest[1] != ' ') {
+				return "", false
+			}
+			if q == '\'' {
+				return strings.Replace(text[1:i], "''", "'", -1), true
+			}
+			key, err := strconv.Unquote(text[:i+1])
+			return key, err == nil
+		}
+		return "", false
+	}
+	m := plainKey.FindStringSubmatch(text)
+	if m == nil || strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", false
+	}
+	return m[1], true
+}
+
+// indexYaml find the lines of keys by their indents, the flow style maps and lists are not indexed
+func indexYaml(ds []byte) *yamlIndex {
+	yi := &yamlIndex{make(map[string]int), nil}
+	items := make(map[string]int)
+	stack := make([]yamlFrame, 0)
+	for i, raw := range strings.Split(string(ds), "\n") {
+		line := strings.TrimRight(stripComment(raw), " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		indent := len(line) - len(text)
+
+		isItem := text == "-" || strings.HasPrefix(text, "- ")
+		for len(stack) != 0 {
+			top := stack[len(stack)-1]
+			// a list can be at the same indent as its key
+			if top.indent > indent || (top.indent == indent && (!isItem || top.item)) {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			break
+		}
+		parent := ""
+		if len(stack) != 0 {
+			parent = stack[len(stack)-1].path
+		}
+
+		if isItem {
+			path := strings.TrimPrefix(fmt.Sprintf("%s/%d", parent, items[parent]), "/")
+			items[parent]++
+			yi.lines[path] = i + 1
+			stack = append(stack, yamlFrame{indent, path, true})
+			rest := strings.TrimLeft(strings.TrimPrefix(text, "-"), " ")
+			indent += len(text) - len(rest)
+			text, parent = rest, path
+		}
+
+		key, ok := yamlKeyOf(text)
+		if !ok {
+			continue
+		}
+		path := strings.TrimPrefix(parent+"/"+key, "/")
+		if _, has := yi.lines[path]; has {
+			yi.dups = append(yi.dups, yamlKey{path, i + 1})
+		} else {
+			yi.lines[path] = i + 1
+		}
+		stack = append(stack, yamlFrame{indent, path, false})
+	}
+	return yi
+}
+
+// configChecker check the settings of a config file one by one
+type configChecker struct {
+	index    *yamlIndex
+	problems []*configProblem
+}
+
+func (cc *configChecker) report(fatal bool, path []string, format string, args ...interface{}) {
+	cc.problems = append(cc.problems, &configProblem{cc.index.line(path...), fatal, fmt.Sprintf(format, args...)})
+}
+
+func (cc *configChecker) warn(path []string, format string, args ...interface{}) {
+	cc.report(false, path, format, args...)
+}
+
+func (cc *configChecker) fail(path []string, format string, args ...interface{}) {
+	cc.report(true, path, format, args...)
+}
+
+// actionNames the names of actions, actions refer to the config checking by reloading, so it is filled in init
+var actionNames = make(map[string]bool)
+
+func init() {
+	for k := range actions {
+		actionNames[k] = true
+	}
+}
+
+var (
+	bindingModes = []string{"all", "normal", "jump", "input", "clip", "task", "hex", "history", "sums"}
+	yamlLine     = regexp.MustCompile(`line (\d+): `)
+)
+
+// checkConfig find the problems of the config file content ds, they are sorted by line
+// an error means a setting is not used, a warning means a setting may not work as expected
+func checkConfig(ds []byte) []*configProblem {
+	cc := &configChecker{indexYaml(ds), nil}
+
+	var mp map[string]interface{}
+	if err := yaml.Unmarshal(ds, &mp); err != nil {
+		line, msg := 0, err.Error()
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = strings.Replace(msg, m[0], "", 1)
+		}
+		return []*configProblem{{line, true, msg}}
+	}
+
+	for _, v := range cc.index.dups {
+		msg := fmt.Sprintf("%s is defined more than once, the last one is used", v.path)
+		if ps := strings.SplitN(v.path, "/", 3); len(ps) == 3 && ps[0] == "binding" {
+			msg = fmt.Sprintf("key %s is bound more than once in %s, the last one is used", ps[2], ps[1])
+		}
+		cc.problems = append(cc.problems, &configProblem{v.line, false, msg})
+	}
+
+	for k, v := range mp {
+		path := []string{k}
+		switch k {
+		case "color":
+			cc.checkColors(v)
+		case "binding":
+			cc.checkBindings(v)
+		case "shell", "editor", "pager":
+			if s, ok := v.(string); !ok || s == "" {
+				cc.fail(path, "%s must be a command", k)
+			}
+		case "single-column-mode", "preview", "watch", "verify-copy":
+			if _, ok := v.(bool); !ok {
+				cc.fail(path, "%s must be true or false", k)
+			}
+		case "load-timeout":
+			if n, ok := v.(int); !ok || n <= 0 {
+				cc.fail(path, "load-timeout must be seconds greater than 0")
+			}
+		case "watch-poll":
+			if n, ok := v.(int); !ok || n < 0 {
+				cc.fail(path, "watch-poll must be seconds, 0 to poll no dir")
+			}
+		case "rate-limit":
+			cc.checkRateLimits(v)
+		case "openers":
+			cc.checkOpeners(v)
+		case "commands":
+			cc.checkCommands(v)
+		default:
+			cc.warn(path, "unknown setting %s", k)
+		}
+	}
+
+	sort.Slice(cc.problems, func(i, j int) bool {
+		pi, pj := cc.problems[i], cc.problems[j]
+		return pi.line < pj.line || (pi.line == pj.line && pi.msg < pj.msg)
+	})
+	return cc.problems
+}
+
+func (cc *configChecker) checkColors(ds interface{}) {
+	dd, ok := ds.(map[interface{}]interface{})
+	if !ok {
+		cc.fail([]string{"color"}, "color must be a map of names to colors")
+		return
+	}
+	for k, v := range dd {
+		kk, vv := fmt.Sprintf("%v", k), fmt.Sprintf("%v", v)
+		if _, ok := colorMap[vv]; !ok {
+			cc.warn([]string{"color", kk}, "invalid color %s of %s, it is one of %s", vv, kk, strings.Join(colorNames(), ", "))
+		}
+	}
+}
+
+func colorNames() []string {
+	re := make([]string, 0, len(colorMap))
+	for k := range colorMap {
+		re = append(re, k)
+	}
+	sort.Strings(re)
+	return re
+}
+
+func isValidKey(key string) bool {
+	_, ok := nameToKey[key]
+	return ok || len([]rune(key)) == 1
+}
+
+// checkAction check the action of a binding, it is an action name or a command started with :
+func (cc *configChecker) checkAction(path []string, action string) {
+	if idx := strings.Index(action, ";"); idx != -1 {
+		action = action[:idx]
+	}
+	action = strings.TrimSpace(action)
+	if !strings.HasPrefix(action, ":") {
+		if !actionNames[action] {
+			cc.warn(path, "unknown action %s", action)
+		}
+		return
+	}
+
+	ws, err := splitWords(action[1:])
+	if err != nil {
+		cc.warn(path, "%s: %s", action, err.Error())
+		return
+	}
+	if _, ok := commands[ws[0].value]; !ok {
+		cc.warn(path, "unknown command %s", ws[0].value)
+	}
+}
+
+func (cc *configChecker) checkBindings(ds interface{}) {
+	dd, ok := ds.(map[interface{}]interface{})
+	if !ok {
+		cc.fail([]string{"binding"}, "binding must be a map of modes to keys")
+		return
+	}
+
+	for m, kbs := range dd {
+		mode := fmt.Sprintf("%v", m)
+		mpath := []string{"binding", mode}
+		known := false
+		for _, v := range bindingModes {
+			known = known || v == mode
+		}
+		if !known {
+			cc.warn(mpath, "unknown mode %s, it is one of %s", mode, strings.Join(bindingModes, ", "))
+			continue
+		}
+		kd, ok := kbs.(map[interface{}]interface{})
+		if !ok {
+			if kbs != nil {
+				cc.fail(mpath, "the bindings of %s must be a map of keys to actions", mode)
+			}
+			continue
+		}
+
+		for k, v := range kd {
+			key := fmt.Sprintf("%v", k)
+			path := append(mpath, key)
+			if !isValidKey(key) {
+				cc.warn(path, "invalid key %s", key)
+			}
+			switch vv := v.(type) {
+			case string:
+				cc.checkAction(path, vv)
+			case map[interface{}]interface{}:
+				for ck, cv := range vv {
+					ckey := fmt.Sprintf("%v", ck)
+					cpath := append(append([]string(nil), path...), ckey)
+					if !isValidKey(ckey) {
+						cc.warn(cpath, "invalid key %s", ckey)
+					}
+					if s, ok := cv.(string); ok {
+						cc.checkAction(cpath, s)
+					} else {
+						cc.fail(cpath, "the action of %s %s must be a string", key, ckey)
+					}
+				}
+			default:
+				cc.fail(path, "the action of %s must be a string or a map of keys to actions", key)
+			}
+		}
+	}
+}
+
+func (cc *configChecker) checkRateLimits(ds interface{}) {
+	dd, ok := ds.(map[interface{}]interface{})
+	if !ok {
+		cc.fail([]string{"rate-limit"}, "rate-limit must be a map of loaders to rates")
+		return
+	}
+	for k, v := range dd {
+		if _, err := model.ParseRate(fmt.Sprintf("%v", v)); err != nil {
+			cc.fail([]string{"rate-limit", fmt.Sprintf("%v", k)}, "%s", err.Error())
+		}
+	}
+}
+
+func (cc *configChecker) checkOpeners(ds interface{}) {
+	dd, ok := ds.([]interface{})
+	if !ok {
+		cc.fail([]string{"openers"}, "openers must be a list of rules")
+		return
+	}
+	for i, v := range dd {
+		path := []string{"openers", strconv.Itoa(i)}
+		vv, ok := v.(map[interface{}]interface{})
+		if !ok {
+			cc.fail(path, "opener %d must be a map", i+1)
+			continue
+		}
+		if len(readStrings(vv["programs"])) == 0 {
+			cc.fail(path, "opener %d has no programs", i+1)
+		}
+		for k := range vv {
+			switch k {
+			case "ext", "glob", "mime", "loader", "programs", "background":
+			default:
+				cc.warn(append(path, fmt.Sprintf("%v", k)), "unknown condition %v of opener %d", k, i+1)
+			}
+		}
+	}
+}
+
+func (cc *configChecker) checkCommands(ds interface{}) {
+	dd, ok := ds.(map[interface{}]interface{})
+	if !ok {
+		cc.fail([]string{"commands"}, "commands must be a map of names to commands")
+		return
+	}
+	for k, v := range dd {
+		name := fmt.Sprintf("%v", k)
+		path := []string{"commands", name}
+		vv, ok := v.(map[interface{}]interface{})
+		if !ok {
+			cc.fail(path, "command %s must be a map", name)
+			continue
+		}
+		if run, _ := vv["run"].(string); run == "" {
+			cc.fail(path, "command %s has nothing to run", name)
+		}
+		if mode, ok := vv["mode"]; ok && mode != runForeground && mode != runBackground && mode != runSilent {
+			cc.fail(append(path, "mode"), "mode of %s must be %s, %s or %s", name, runForeground, runBackground, runSilent)
+		}
+		key, _ := vv["key"].(string)
+		if key == "" {
+			continue
+		}
+		kpath := append(path, "key")
+		if !isValidKey(key) {
+			cc.warn(kpath, "invalid key %s of command %s", key, name)
+		} else if line := cc.index.line("binding", "normal", key); line != 0 {
+			cc.warn(kpath, "key %s of command %s overrides the binding at line %d", key, name, line)
+		}
+	}
+}
+
+// userConfig the path of the config file of user
+func userConfig() string {
+	return configFile
+}
+
+// checkUserConfig check the config file of user, nil if there is no such file
+func checkUserConfig() []*configProblem {
+	ds, err := ioutil.ReadFile(userConfig())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return []*configProblem{{0, true, err.Error()}}
+	}
+	return checkConfig(ds)
+}
+
+// runCheckConfig print the problems of the config file of user for --check-config
+// the exit code is 1 if there is any error
+func runCheckConfig() int {
+	path := userConfig()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Printf("%s does not exist, the default config is used\n", path)
+		return 0
+	}
+
+	code := 0
+	ps := checkUserConfig()
+	for _, v := range ps {
+		fmt.Fprintln(os.Stderr, v.format(path))
+		if v.fatal {
+			code = 1
+		}
+	}
+	if len(ps) == 0 {
+		fmt.Printf("%s is ok\n", path)
+	}
+	return code
+}
+
+// configWarning the first problem of the config file of user shown at startup, nil if there is no problem
+func configWarning() error {
+	ps := checkUserConfig()
+	if len(ps) == 0 {
+		return nil
+	}
+	msg := ps[0].format(filepath.Base(userConfig()))
+	if len(ps) > 1 {
+		msg = fmt.Sprintf("%s (%d more, see fff --check-config)", msg, len(ps)-1)
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jacokoo/fff/ui"
+)
+
+func TestCheckConfig(t *testing.T) {
+	if ps := checkConfig(data); len(ps) != 0 {
+		t.Errorf("expect no problem in the default config, got %s", ps[0].format("data"))
+	}
+
+	bad := `color:
+  tab: purple # not a color
+binding:
+  normal:
+    "x": ActionQuit
+    "x": ActionQuit
+    "ab": ActionNope
+    "z":
+      "1": ":nope"
+      "2": [a]
+  visual:
+    "v": ActionQuit
+openers:
+  - ext: [png]
+  - programs: feh
+    when: always
+commands:
+  up:
+    run: upload {marked}
+    mode: later
+    key: x
+shell: [sh]
+`
+	expect := []string{
+		"c:2: warning: invalid color purple of tab",
+		"c:6: warning: key x is bound more than once in normal, the last one is used",
+		"c:7: warning: invalid key ab",
+		"c:7: warning: unknown action ActionNope",
+		"c:9: warning: unknown command nope",
+		"c:10: error: the action of z 2 must be a string",
+		"c:11: warning: unknown mode visual",
+		"c:14: error: opener 1 has no programs",
+		"c:16: warning: unknown condition when of opener 2",
+		"c:20: error: mode of up must be",
+		"c:21: warning: key x of command up overrides the binding at line 5",
+		"c:22: error: shell must be a command",
+	}
+	ps := checkConfig([]byte(bad))
+	if len(ps) != len(expect) {
+		for _, v := range ps {
+			t.Log(v.format("c"))
+		}
+		t.Fatalf("expect %d problems, got %d", len(expect), len(ps))
+	}
+	for i, v := range ps {
+		if s := v.format("c"); !strings.HasPrefix(s, expect[i]) {
+			t.Errorf("expect %s, got %s", expect[i], s)
+		}
+	}
+
+	ps = checkConfig([]byte("binding:\n  normal: [\n"))
+	if len(ps) != 1 || !ps[0].fatal || ps[0].format("c") != "c:2: error: yaml: did not find expected node content" {
+		t.Errorf("expect a syntax error at line 2, got %s", ps[0].format("c"))
+	}
+
+	// the wrong settings are left out instead of panicking
+	cfg := &config{colors: make(map[string]*ui.Color), rateLimits: make(map[string]int64), userCommands: make(map[string]*userCommand)}
+	readYaml([]byte(bad), cfg)
+	if cfg.shell != "" || len(cfg.openers) != 1 || len(cfg.normalKbds) != 2 {
+		t.Errorf("unexpected config %q %d %d", cfg.shell, len(cfg.openers), len(cfg.normalKbds))
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/jacokoo/fff/model"
+	"github.com/jacokoo/fff/ui"
+)
+
+// changes in this duration are applied together, so a build or a download does not refresh too often
+const watchDebounce = 200 * time.Millisecond
+
+var (
+	watcher    *model.Watcher
+	watchDirty = make(map[string]bool)
+
+	// the running reads of the changed columns, a newer change of a column cancels its read
+	watchReads = make(map[model.Column]context.CancelFunc)
+)
+
+func startWatch() {
+	if !cfg.watch {
+		return
+	}
+
+	w, err := model.NewWatcher(watchDebounce, cfg.watchPoll)
+	if err != nil {
+		return
+	}
+	watcher = w
+
+	go func() {
+		for paths := range w.C {
+			changed := paths
+			post(func() {
+				for _, v := range changed {
+					watchDirty[v] = true
+				}
+				applyWatch()
+			})
+		}
+	}()
+}
+
+// updateWatch watch the dirs of current group and apply the changes delayed by other modes
+func updateWatch() {
+	if watcher == nil {
+		return
+	}
+
+	items := make([]model.FileItem, 0)
+	for _, v := range wo.CurrentGroup().Columns() {
+		if !v.IsLoading() && !v.IsPartial() {
+			items = append(items, v.File())
+		}
+	}
+	watcher.Watch(items)
+	applyWatch()
+}
+
+// applyWatch reload the changed columns of the current group in background
+// the columns are not touched in other modes, so that jump items and popups keep in place
+func applyWatch() {
+	if mode != ModeNormal {
+		return
+	}
+
+	if len(watchDirty) == 0 {
+		return
+	}
+	dirty := watchDirty
+	watchDirty = make(map[string]bool)
+
+	gu := wo.CurrentGroup()
+	for _, co := range gu.Columns() {
+		if co.IsLoading() || co.IsPartial() || !dirty[co.Path()] {
+			continue
+		}
+		reloadWatched(gu, co)
+	}
+}
+
+// reloadWatched read the dir of co again in background, the items are applied in the state loop
+// they are dropped if co is closed or shows another dir before the read finished
+func reloadWatched(gu model.Group, co model.Column) {
+	if cancel, ok := watchReads[co]; ok {
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	watchReads[co] = cancel
+
+	item, timeout := co.File(), cfg.loadTimeout
+	startJob(func() {
+		items, err := model.ReadDir(ctx, item, timeout, nil)
+		post(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			cancel()
+			delete(watchReads, co)
+
+			if err != nil || !gu.Contains(co) || co.Path() != item.Path() || co.IsLoading() || co.IsPartial() {
+				return
+			}
+			if mode != ModeNormal {
+				watchDirty[co.Path()] = true
+				return
+			}
+
+			co.Reload(items)
+			if wo.CurrentGroup() == gu {
+				ui.ColumnsRefreshEvent.Send(gu)
+				updatePreview()
+			}
+		})
+	})
+}
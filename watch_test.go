@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoopWatchReload(t *testing.T) {
+	tmp := tempDir(t, "a")
+	defer os.RemoveAll(tmp)
+	os.MkdirAll(filepath.Join(tmp, "sub"), 0755)
+	startLoop(tmp)
+
+	count := func() int {
+		ch := make(chan int)
+		post(func() { ch <- len(wo.CurrentGroup().Current().Files()) })
+		return <-ch
+	}
+
+	ioutil.WriteFile(filepath.Join(tmp, "b"), nil, 0644)
+	post(func() {
+		watchDirty[tmp] = true
+		applyWatch()
+	})
+	waitJobs(0)
+	if n := count(); n != 3 {
+		t.Fatalf("the changed dir is not read again, got %d items", n)
+	}
+
+	// the column shows another dir before the read is done, the items of tmp are dropped
+	ioutil.WriteFile(filepath.Join(tmp, "c"), nil, 0644)
+	post(func() {
+		watchDirty[tmp] = true
+		applyWatch()
+		co := wo.CurrentGroup().Current()
+		co.SelectByName("sub")
+		fi, _ := co.CurrentFile()
+		co.Refresh(fi)
+	})
+	waitJobs(0)
+	if n := count(); n != 0 {
+		t.Errorf("the items of the old dir are applied, got %d items", n)
+	}
+}